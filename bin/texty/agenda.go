@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gotk3/gotk3/glib"
+)
+
+// agendaLoop displays the upcoming events from the window's calendar files,
+// reloading them when they change and updating relative times every minute.
+func (w *window) agendaLoop() {
+	a := w.config.Agenda
+	names := lookupLocale("")
+
+	patterns := make([]string, len(a.Files))
+	for i, pattern := range a.Files {
+		patterns[i] = expandHome(pattern)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("warning: failed to create watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	// directories are watched rather than files, since sync tools such as
+	// vdirsyncer add and replace files
+	watched := make(map[string]bool)
+	watch := func(dir string) {
+		if watched[dir] {
+			return
+		}
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("warning: failed to add watcher: %v", err)
+			return
+		}
+		watched[dir] = true
+	}
+	for _, pattern := range patterns {
+		if dir := filepath.Dir(pattern); !hasGlobMeta(dir) {
+			watch(dir)
+		}
+	}
+
+	var events []*icsEvent
+	load := func() {
+		var files []string
+		events, files = loadIcsFiles(patterns)
+		for _, file := range files {
+			watch(filepath.Dir(file))
+		}
+	}
+
+	render := func() {
+		now := time.Now()
+		end := now.AddDate(0, 0, a.Days)
+		upcoming := occurrences(events, now, end)
+		lines := make([]string, 0, a.Count)
+		for _, o := range upcoming[:min(len(upcoming), a.Count)] {
+			values := agendaValues(o, now, names)
+			for k, v := range values {
+				values[k] = glib.MarkupEscapeText(v)
+			}
+			lines = append(lines, expandPlaceholders(a.Format, values))
+		}
+		w.showText(strings.Join(lines, "\n"))
+	}
+
+	load()
+	render()
+
+	// relative times are updated at the start of every minute
+	timer := time.NewTimer(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	defer timer.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !matchesAny(patterns, event.Name) {
+				continue
+			}
+			load()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watcher error: %v", err)
+			continue
+		case <-timer.C:
+			timer.Reset(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+		}
+
+		if w.closed {
+			return
+		}
+		render()
+	}
+}
+
+// loadIcsFiles parses the iCalendar files matching any of patterns, returning
+// their events and the files that were read. Files that can't be parsed are
+// logged and skipped.
+func loadIcsFiles(patterns []string) ([]*icsEvent, []string) {
+	var events []*icsEvent
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			log.Printf("warning: invalid calendar file pattern: %v", err)
+			continue
+		}
+		for _, path := range matches {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Printf("warning: failed to open calendar file: %v", err)
+				continue
+			}
+			parsed, err := parseIcs(f)
+			f.Close()
+			if err != nil {
+				log.Printf("warning: failed to parse calendar file %s: %v", path, err)
+				continue
+			}
+			events = append(events, parsed...)
+			files = append(files, path)
+		}
+	}
+	return events, files
+}
+
+// agendaValues returns the placeholders for an occurrence displayed at now.
+func agendaValues(o icsOccurrence, now time.Time, names *localeNames) map[string]string {
+	start := o.start.In(now.Location())
+	end := o.end.In(now.Location())
+
+	values := map[string]string{
+		"summary":  o.event.summary,
+		"location": o.event.location,
+		"date":     strftime(start, "%a %-d %b", names),
+		"time":     strftime(start, "%H:%M", names),
+		"end":      strftime(end, "%H:%M", names),
+		"when":     relativeTime(o, now, names),
+	}
+	if o.event.allDay {
+		values["time"] = "all day"
+		values["end"] = ""
+	}
+	return values
+}
+
+// relativeTime describes when an occurrence happens relative to now, e.g.
+// "now", "in 25 min", "tomorrow 09:00" or "Mon 21 Oct".
+func relativeTime(o icsOccurrence, now time.Time, names *localeNames) string {
+	start := o.start.In(now.Location())
+	year, month, day := now.Date()
+	tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+	dayAfter := tomorrow.AddDate(0, 0, 1)
+
+	if o.event.allDay {
+		switch {
+		case start.Before(tomorrow):
+			return "today"
+		case start.Before(dayAfter):
+			return "tomorrow"
+		}
+		return strftime(start, "%a %-d %b", names)
+	}
+
+	until := start.Sub(now)
+	switch {
+	case until <= 0:
+		return "now"
+	case until < time.Hour:
+		// rounded up, so an event starting in 30 seconds is "in 1 min"
+		return fmt.Sprintf("in %d min", (until+time.Minute-1)/time.Minute)
+	case start.Before(tomorrow):
+		until = until.Round(time.Minute)
+		return fmt.Sprintf("in %d h %d min", until/time.Hour, until/time.Minute%60)
+	case start.Before(dayAfter):
+		return strftime(start, "tomorrow %H:%M", names)
+	}
+	return strftime(start, "%a %-d %b %H:%M", names)
+}
+
+// expandHome replaces a leading ~ in path with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// ANSI escape sequences are translated to Pango markup. Only SGR (colors and
+// text attributes) is supported: other sequences, such as cursor movement or
+// OSC titles, are removed.
+
+// the 16 basic colors, as displayed by xterm
+var ansiColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// sgrState is the set of attributes selected by SGR sequences.
+type sgrState struct {
+	fg, bg    string
+	bold      bool
+	dim       bool
+	italic    bool
+	underline bool
+	strike    bool
+	reverse   bool
+}
+
+// attrs returns the Pango attributes of s, or "" if s is the default state.
+func (s sgrState) attrs() string {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+	}
+
+	var attrs []string
+	if fg != "" {
+		attrs = append(attrs, `foreground="`+fg+`"`)
+	}
+	if bg != "" {
+		attrs = append(attrs, `background="`+bg+`"`)
+	}
+	if s.bold {
+		attrs = append(attrs, `weight="bold"`)
+	}
+	if s.dim {
+		attrs = append(attrs, `alpha="50%"`)
+	}
+	if s.italic {
+		attrs = append(attrs, `style="italic"`)
+	}
+	if s.underline {
+		attrs = append(attrs, `underline="single"`)
+	}
+	if s.strike {
+		attrs = append(attrs, `strikethrough="true"`)
+	}
+	return strings.Join(attrs, " ")
+}
+
+// apply updates s with the parameters of an SGR sequence.
+func (s *sgrState) apply(params string) {
+	// colon separated sub-parameters, e.g. 38:2::255:0:0, are treated like
+	// semicolon separated ones
+	fields := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	if len(fields) == 0 {
+		*s = sgrState{}
+		return
+	}
+
+	codes := make([]int, len(fields))
+	for i, f := range fields {
+		codes[i], _ = strconv.Atoi(f)
+	}
+
+	for i := 0; i < len(codes); i++ {
+		switch c := codes[i]; {
+		case c == 0:
+			*s = sgrState{}
+		case c == 1:
+			s.bold = true
+		case c == 2:
+			s.dim = true
+		case c == 3:
+			s.italic = true
+		case c == 4 || c == 21:
+			s.underline = true
+		case c == 7:
+			s.reverse = true
+		case c == 9:
+			s.strike = true
+		case c == 22:
+			s.bold, s.dim = false, false
+		case c == 23:
+			s.italic = false
+		case c == 24:
+			s.underline = false
+		case c == 27:
+			s.reverse = false
+		case c == 29:
+			s.strike = false
+		case c >= 30 && c <= 37:
+			s.fg = ansiColors[c-30]
+		case c >= 90 && c <= 97:
+			s.fg = ansiColors[c-90+8]
+		case c == 39:
+			s.fg = ""
+		case c >= 40 && c <= 47:
+			s.bg = ansiColors[c-40]
+		case c >= 100 && c <= 107:
+			s.bg = ansiColors[c-100+8]
+		case c == 49:
+			s.bg = ""
+		case c == 38 || c == 48:
+			color, n := extendedColor(codes[i+1:])
+			i += n
+			if color == "" {
+				continue
+			}
+			if c == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor parses the arguments of a 256 color (5;n) or truecolor
+// (2;r;g;b) SGR parameter, returning the color and the number of parameters
+// used.
+func extendedColor(codes []int) (string, int) {
+	if len(codes) == 0 {
+		return "", 0
+	}
+	switch codes[0] {
+	case 5:
+		if len(codes) < 2 {
+			return "", len(codes)
+		}
+		return xtermColor(codes[1]), 2
+	case 2:
+		// some programs include an (empty) color space id: 38:2::r:g:b
+		if len(codes) < 4 {
+			return "", len(codes)
+		}
+		rgb := codes[1:4]
+		return fmt.Sprintf("#%02x%02x%02x", uint8(rgb[0]), uint8(rgb[1]), uint8(rgb[2])), 4
+	}
+	return "", 1
+}
+
+// xtermColor returns a color of the xterm 256 color palette.
+func xtermColor(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	}
+	gray := 8 + (n-232)*10
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// ansiToMarkup converts text containing ANSI escape sequences to Pango
+// markup. Attributes carry over to the following lines, but spans are closed
+// at the end of each line since lines are displayed by separate labels.
+func ansiToMarkup(text string) string {
+	var b strings.Builder
+	var state sgrState
+	open := false
+
+	closeSpan := func() {
+		if open {
+			b.WriteString("</span>")
+			open = false
+		}
+	}
+	openSpan := func() {
+		if attrs := state.attrs(); attrs != "" {
+			b.WriteString("<span " + attrs + ">")
+			open = true
+		}
+	}
+
+	start := 0
+	flush := func(end int) {
+		if start < end {
+			b.WriteString(glib.MarkupEscapeText(text[start:end]))
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\n':
+			flush(i)
+			closeSpan()
+			b.WriteByte('\n')
+			i++
+			start = i
+			// the span is reopened lazily, so empty lines stay empty
+			continue
+		case c == 0x1b:
+			flush(i)
+			n, params, sgr := parseEscape(text[i:])
+			i += n
+			start = i
+			if sgr {
+				closeSpan()
+				state.apply(params)
+			}
+			continue
+		case c < 0x20 && c != '\t' || c == 0x7f:
+			// other control characters, e.g. carriage returns and bells
+			flush(i)
+			i++
+			start = i
+			continue
+		}
+
+		if !open && i == start {
+			openSpan()
+		}
+		i++
+	}
+	flush(len(text))
+	closeSpan()
+	return b.String()
+}
+
+// parseEscape parses the escape sequence at the start of s, returning its
+// length and, for SGR sequences, their parameters.
+func parseEscape(s string) (n int, params string, sgr bool) {
+	if len(s) < 2 {
+		return len(s), "", false
+	}
+	switch s[1] {
+	case '[':
+		// CSI: parameter bytes, intermediate bytes and a final byte
+		i := 2
+		for i < len(s) && s[i] >= 0x30 && s[i] <= 0x3f {
+			i++
+		}
+		paramsEnd := i
+		for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+			i++
+		}
+		if i >= len(s) {
+			return len(s), "", false
+		}
+		if s[i] == 'm' && paramsEnd == i {
+			return i + 1, s[2:paramsEnd], true
+		}
+		return i + 1, "", false
+	case ']', 'P', '_', '^', 'X':
+		// OSC and other strings, terminated by BEL or ST (ESC \)
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1, "", false
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2, "", false
+			}
+		}
+		return len(s), "", false
+	case '(', ')', '*', '+', '#', '%':
+		// character set designation and similar three byte sequences
+		return min(3, len(s)), "", false
+	}
+	return 2, "", false
+}
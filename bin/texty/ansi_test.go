@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestAnsiToMarkup(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[31mred\x1b[0m plain", `<span foreground="#cd0000">red</span> plain`},
+		{"\x1b[1;3;4mall\x1b[22;23mu\x1b[m", `<span weight="bold" style="italic" underline="single">all</span><span underline="single">u</span>`},
+		{"\x1b[38;5;196;48;5;244mx", `<span foreground="#ff0000" background="#808080">x</span>`},
+		{"\x1b[38;2;1;2;3mx\x1b[39mdefault", `<span foreground="#010203">x</span>default`},
+		{"\x1b[38:2::1:2:3mx", `<span foreground="#010203">x</span>`},
+		{"\x1b[92;7mx", `<span background="#00ff00">x</span>`},
+		// attributes carry over to the next line
+		{"\x1b[34mone\ntwo\x1b[0m\n\nthree", "<span foreground=\"#0000ee\">one</span>\n<span foreground=\"#0000ee\">two</span>\n\nthree"},
+		// cursor movement, OSC and control characters are removed
+		{"\x1b[2K\x1b[1Aa\x1b]0;title\x07b\x1b]8;;http://x\x1b\\c\rd\x1b(Be", "abcde"},
+	}
+	for _, tt := range tests {
+		if got := ansiToMarkup(tt.text); got != tt.want {
+			t.Errorf("ansiToMarkup(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
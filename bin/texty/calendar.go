@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"texty"
+	"time"
+	"unicode/utf8"
+)
+
+// rows of days in a month block, so months line up when shown side by side
+const calendarWeeks = 6
+
+// calendarLoop displays the window's calendar, redrawing it when the date
+// changes.
+func (w *window) calendarLoop() {
+	c := w.config.Calendar
+	names := lookupLocale(c.Locale)
+
+	shown := ""
+	for !w.closed {
+		now := time.Now()
+		if date := now.Format(time.DateOnly); date != shown {
+			w.showText(strings.Join(renderCalendar(now, c, names), "\n"))
+			shown = date
+		}
+
+		// wake up at midnight, but at least every minute so that suspending or
+		// changing the time zone doesn't delay the rollover
+		year, month, day := now.Date()
+		midnight := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+		time.Sleep(min(time.Until(midnight), time.Minute))
+	}
+}
+
+// renderCalendar returns the lines of the calendar for the month of today and
+// its surrounding months, which are displayed side by side in a monospace
+// font.
+func renderCalendar(today time.Time, c *texty.Calendar, names *localeNames) []string {
+	year, month, _ := today.Date()
+	first := -(c.Months - 1) / 2
+
+	var blocks [][]string
+	for i := range c.Months {
+		start := time.Date(year, month+time.Month(first+i), 1, 0, 0, 0, 0, today.Location())
+		blocks = append(blocks, renderMonth(start, today, c, names))
+	}
+
+	lines := make([]string, len(blocks[0]))
+	for i := range lines {
+		parts := make([]string, len(blocks))
+		for j, block := range blocks {
+			parts[j] = block[i]
+		}
+		lines[i] = "<tt>" + strings.Join(parts, "   ") + "</tt>"
+	}
+	return lines
+}
+
+// renderMonth returns the lines of a single month starting at start: its
+// name, the names of the days and a row for each week.
+func renderMonth(start, today time.Time, c *texty.Calendar, names *localeNames) []string {
+	// two characters for each day and one between them
+	width := 7*3 - 1
+	if c.WeekNumbers {
+		width += 3
+	}
+
+	lines := make([]string, 0, 2+calendarWeeks)
+	title := names.months[start.Month()-1] + " " + strconv.Itoa(start.Year())
+	lines = append(lines, padCenter(title, width))
+
+	var header strings.Builder
+	if c.WeekNumbers {
+		header.WriteString("   ")
+	}
+	for i := range 7 {
+		if i > 0 {
+			header.WriteByte(' ')
+		}
+		day := (int(c.WeekStart) + i) % 7
+		name := []rune(names.shortDays[day])
+		header.WriteString(fmt.Sprintf("%-2s", string(name[:min(len(name), 2)])))
+	}
+	lines = append(lines, header.String())
+
+	// the first row starts on the week start before the 1st
+	offset := (int(start.Weekday()) - int(c.WeekStart) + 7) % 7
+	day := start.AddDate(0, 0, -offset)
+	for range calendarWeeks {
+		if day.Month() != start.Month() && day.AddDate(0, 0, 6).Month() != start.Month() {
+			lines = append(lines, strings.Repeat(" ", width))
+			day = day.AddDate(0, 0, 7)
+			continue
+		}
+
+		var row strings.Builder
+		if c.WeekNumbers {
+			// the ISO week of a row is the week of its Thursday
+			thursday := day.AddDate(0, 0, (int(time.Thursday)-int(day.Weekday())+7)%7)
+			_, week := thursday.ISOWeek()
+			fmt.Fprintf(&row, "%2d ", week)
+		}
+		for i := range 7 {
+			if i > 0 {
+				row.WriteByte(' ')
+			}
+			row.WriteString(renderDay(day, start.Month(), today, c))
+			day = day.AddDate(0, 0, 1)
+		}
+		lines = append(lines, row.String())
+	}
+	return lines
+}
+
+// renderDay returns a two character wide cell for day, highlighting today and
+// weekends. Days outside of month are left blank.
+func renderDay(day time.Time, month time.Month, today time.Time, c *texty.Calendar) string {
+	if day.Month() != month {
+		return "  "
+	}
+
+	text := strconv.Itoa(day.Day())
+	padding := ""
+	if len(text) < 2 {
+		// the padding isn't highlighted, so underlines only cover the number
+		padding = " "
+	}
+
+	y1, m1, d1 := day.Date()
+	y2, m2, d2 := today.Date()
+	if y1 == y2 && m1 == m2 && d1 == d2 && c.Today != "" {
+		text = "<span " + c.Today + ">" + text + "</span>"
+	}
+	if weekday := day.Weekday(); (weekday == time.Saturday || weekday == time.Sunday) && c.Weekend != "" {
+		text = "<span " + c.Weekend + ">" + text + "</span>"
+	}
+	return padding + text
+}
+
+// calendarLineClasses returns the CSS classes of the lines of a calendar
+// rendered for today: calendar-title for month names, calendar-weekdays for
+// day names and calendar-week for weeks, plus current-week for today's week.
+// Lines are labels, so individual days can't have classes.
+func calendarLineClasses(today time.Time, c *texty.Calendar) [][]string {
+	year, month, day := today.Date()
+	start := time.Date(year, month, 1, 0, 0, 0, 0, today.Location())
+	offset := (int(start.Weekday()) - int(c.WeekStart) + 7) % 7
+	current := 2 + (offset+day-1)/7
+
+	classes := make([][]string, 2+calendarWeeks)
+	classes[0] = []string{"calendar-title"}
+	classes[1] = []string{"calendar-weekdays"}
+	for i := 2; i < len(classes); i++ {
+		classes[i] = []string{"calendar-week"}
+		if i == current {
+			classes[i] = append(classes[i], "current-week")
+		}
+	}
+	return classes
+}
+
+// padCenter centers s in a field of the given width.
+func padCenter(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return s
+	}
+	left := (width - n) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-n-left)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"texty"
+	"time"
+)
+
+func TestRenderCalendar(t *testing.T) {
+	today := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	c := &texty.Calendar{Months: 1, WeekStart: time.Monday, Today: "weight=\"bold\""}
+
+	want := []string{
+		"<tt>    October 2026    </tt>",
+		"<tt>Mo Tu We Th Fr Sa Su</tt>",
+		"<tt>          1  2  3  4</tt>",
+		"<tt> 5  6  7  8  9 10 11</tt>",
+		"<tt>12 13 14 15 16 17 18</tt>",
+		"<tt><span weight=\"bold\">19</span> 20 21 22 23 24 25</tt>",
+		"<tt>26 27 28 29 30 31   </tt>",
+		"<tt>                    </tt>",
+	}
+	got := renderCalendar(today, c, englishNames)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("renderCalendar() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRenderCalendarOptions(t *testing.T) {
+	today := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	c := &texty.Calendar{Months: 3, WeekStart: time.Sunday, WeekNumbers: true, Weekend: "alpha=\"50%\""}
+
+	got := renderCalendar(today, c, lookupLocale("de_DE.UTF-8"))
+	if len(got) != 2+calendarWeeks {
+		t.Fatalf("got %d lines, want %d", len(got), 2+calendarWeeks)
+	}
+	// December, January and February side by side
+	if want := "<tt>     Dezember 2025              Januar 2026              Februar 2026      </tt>"; got[0] != want {
+		t.Errorf("title line = %q, want %q", got[0], want)
+	}
+	if want := "   So Mo Di Mi Do Fr Sa"; !strings.HasPrefix(got[1], "<tt>"+want) {
+		t.Errorf("header line = %q, want prefix %q", got[1], want)
+	}
+	// the week of Thursday December 4th
+	if want := "<tt>49 "; !strings.HasPrefix(got[2], want) {
+		t.Errorf("first week = %q, want prefix %q", got[2], want)
+	}
+	// the last week of December is ISO week 1 of 2026
+	if want := "<tt> 1 <span alpha=\"50%\">28</span> 29 30 31   "; !strings.HasPrefix(got[6], want) {
+		t.Errorf("last week of December = %q, want prefix %q", got[6], want)
+	}
+}
+
+func TestCalendarLineClasses(t *testing.T) {
+	tests := []struct {
+		today     time.Time
+		weekStart time.Weekday
+		want      int
+	}{
+		// October 2026 starts on a Thursday
+		{time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC), time.Monday, 5},
+		{time.Date(2026, time.October, 4, 0, 0, 0, 0, time.UTC), time.Monday, 2},
+		{time.Date(2026, time.October, 4, 0, 0, 0, 0, time.UTC), time.Sunday, 3},
+		{time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC), time.Sunday, 6},
+	}
+	for _, tt := range tests {
+		c := &texty.Calendar{Months: 1, WeekStart: tt.weekStart}
+		classes := calendarLineClasses(tt.today, c)
+		if len(classes) != 2+calendarWeeks {
+			t.Fatalf("got %d lines, want %d", len(classes), 2+calendarWeeks)
+		}
+		if classes[0][0] != "calendar-title" || classes[1][0] != "calendar-weekdays" {
+			t.Errorf("%v: header classes = %v", tt.today, classes[:2])
+		}
+		for i, line := range classes[2:] {
+			current := slices.Contains(line, "current-week")
+			if current != (i+2 == tt.want) {
+				t.Errorf("%v, week start %v: line %d classes = %v, want current-week on line %d", tt.today, tt.weekStart, i+2, line, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// clockLoop displays the window's clocks, ticking at the start of every
+// second or minute depending on their formats.
+func (w *window) clockLoop() {
+	type clock struct {
+		format   string
+		location *time.Location
+		names    *localeNames
+	}
+
+	clocks := make([]clock, 0, len(w.config.Clocks))
+	period := time.Minute
+	for _, c := range w.config.Clocks {
+		location := time.Local
+		if c.TimeZone != "" {
+			// already validated
+			location, _ = time.LoadLocation(c.TimeZone)
+		}
+		clocks = append(clocks, clock{
+			format:   c.Format,
+			location: location,
+			names:    lookupLocale(c.Locale),
+		})
+		period = min(period, strftimeResolution(c.Format))
+	}
+
+	lines := make([]string, len(clocks))
+	for !w.closed {
+		now := time.Now()
+		for i, c := range clocks {
+			lines[i] = strftime(now.In(c.location), c.format, c.names)
+		}
+		w.showText(strings.Join(lines, "\n"))
+
+		// sleep until the next tick, aligned to the wall clock
+		time.Sleep(time.Until(now.Truncate(period).Add(period)))
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// directory containing the loaded config file, exported to commands as
+// TEXTY_CONFIG_DIR
+var configDir string
+
+// command builds the window's command, applying its shell mode, environment
+// and working directory. The command runs in its own process group, which is
+// killed as a whole when ctx is done.
+func (w *window) command(ctx context.Context) *exec.Cmd {
+	return w.commandFor(ctx, w.config.Command, w.config.CommandShell)
+}
+
+// commandFor builds a command like command does, for other commands run on
+// behalf of the window.
+func (w *window) commandFor(ctx context.Context, args []string, shell bool) *exec.Cmd {
+	var c *exec.Cmd
+	if shell {
+		c = exec.CommandContext(ctx, "sh", "-c", strings.Join(args, " "))
+	} else {
+		c = exec.CommandContext(ctx, args[0], args[1:]...)
+	}
+
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+
+	output := ""
+	if o := w.output.Load(); o != nil {
+		output = *o
+	}
+
+	c.Env = append(os.Environ(),
+		"TEXTY_WINDOW_ID="+w.config.Id,
+		"TEXTY_CONFIG_DIR="+configDir,
+		"TEXTY_OUTPUT="+output,
+	)
+	for k, v := range w.config.Env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+
+	if w.config.Cwd != nil {
+		c.Dir = *w.config.Cwd
+	}
+
+	return c
+}
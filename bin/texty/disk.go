@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"texty"
+)
+
+// diskSource reports the usage of mounted filesystems, one line per mount.
+type diskSource struct {
+	paths  []string
+	mounts bool
+
+	// last error of each failing path, so it's only logged once
+	failed map[string]string
+}
+
+func newDiskSource(m *texty.Metric) *diskSource {
+	s := &diskSource{paths: m.Paths, mounts: m.Options["mounts"] == "true"}
+	if len(s.paths) == 0 && !s.mounts {
+		s.paths = []string{"/"}
+	}
+	return s
+}
+
+func (s *diskSource) rows() ([]map[string]string, error) {
+	paths := s.paths
+	if s.mounts {
+		mounts, err := readMounts()
+		if err != nil {
+			return nil, err
+		}
+		paths = slices.Clone(paths)
+		for _, mount := range mounts {
+			if !slices.Contains(paths, mount) {
+				paths = append(paths, mount)
+			}
+		}
+	}
+
+	// a stale or unreachable mount only removes its own row, unless every
+	// path fails
+	rows := make([]map[string]string, 0, len(paths))
+	var lastErr error
+	for _, path := range paths {
+		values, err := diskUsage(path)
+		if err != nil {
+			if warning := err.Error(); warning != s.failed[path] {
+				log.Printf("warning: skipping disk: %s", warning)
+				if s.failed == nil {
+					s.failed = make(map[string]string)
+				}
+				s.failed[path] = warning
+			}
+			lastErr = err
+			continue
+		}
+		delete(s.failed, path)
+		rows = append(rows, values)
+	}
+	if len(rows) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return rows, nil
+}
+
+// diskUsage returns the usage of the filesystem containing path. Like df, the
+// percentage is relative to the space available to unprivileged users.
+func diskUsage(path string) (map[string]string, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, &os.PathError{Op: "statfs", Path: path, Err: err}
+	}
+
+	size := uint64(st.Bsize)
+	total := st.Blocks * size
+	used := (st.Blocks - st.Bfree) * size
+	free := st.Bavail * size
+
+	return map[string]string{
+		"path":    path,
+		"total":   formatBytes(total),
+		"used":    formatBytes(used),
+		"free":    formatBytes(free),
+		"percent": formatPercent(percent(used, used+free)),
+	}, nil
+}
+
+// readMounts returns the mount points of real filesystems from
+// /proc/self/mounts, skipping pseudo filesystems such as proc or tmpfs, and
+// FUSE filesystems such as sshfs or the document portal, whose types are
+// subtypes of fuse, e.g. fuse.sshfs.
+func readMounts() ([]string, error) {
+	pseudo, err := readPseudoFilesystems()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(procRoot, "self/mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// device mount-point fstype options dump pass
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		if fstype, _, _ := strings.Cut(fields[2], "."); pseudo[fstype] {
+			continue
+		}
+		mount := unescapeMountPath(fields[1])
+		if !slices.Contains(mounts, mount) {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts, s.Err()
+}
+
+// readPseudoFilesystems returns the filesystem types that aren't backed by a
+// device, which are marked nodev in /proc/filesystems.
+func readPseudoFilesystems() (map[string]bool, error) {
+	f, err := os.Open(filepath.Join(procRoot, "filesystems"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pseudo := make(map[string]bool)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[0] == "nodev" {
+			pseudo[fields[1]] = true
+		}
+	}
+	return pseudo, s.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (e.g. `\040` for a space) used
+// for special characters in /proc/self/mounts.
+func unescapeMountPath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
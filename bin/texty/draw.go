@@ -3,11 +3,16 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
-	"os/exec"
 	"strings"
+	"texty"
+	"time"
 
 	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
@@ -18,6 +23,9 @@ func (w *window) getText() (string, error) {
 		return *w.config.Text, nil
 	}
 	if w.config.File != nil {
+		if w.config.FileTail != nil || w.config.FileFilter != nil {
+			return w.readFileTail()
+		}
 		text, err := os.ReadFile(*w.config.File)
 		if err != nil {
 			return "", err
@@ -25,40 +33,159 @@ func (w *window) getText() (string, error) {
 		return string(text), nil
 	}
 
-	cmd, err := exec.Command(w.config.Command[0], w.config.Command[1:]...).Output()
+	if len(w.metrics) > 0 {
+		return w.metricsText()
+	}
+
+	ctx := context.Background()
+	if w.config.Timeout != nil && *w.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(*w.config.Timeout))
+		defer cancel()
+	}
+
+	cmd, err := w.command(ctx).Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// logged by the caller
+		return "", fmt.Errorf("command for window %s timed out after %v", w.config.Id, time.Duration(*w.config.Timeout))
+	}
 	if err != nil {
 		return "", err
 	}
 	return string(cmd), nil
 }
 
+// draw refreshes the window's content. At most one refresh runs at a time;
+// depending on the window's overlap policy, a refresh requested while another
+// one is running is either skipped or queued to run right after it.
 func (w *window) draw() {
+	w.refreshMu.Lock()
+	if w.refreshing {
+		if w.config.Overlap != nil && *w.config.Overlap == texty.OverlapQueue {
+			w.refreshPending = true
+		}
+		w.refreshMu.Unlock()
+		return
+	}
+	w.refreshing = true
+	w.refreshMu.Unlock()
+
+	for {
+		w.refresh()
+
+		w.refreshMu.Lock()
+		if !w.refreshPending {
+			w.refreshing = false
+			w.refreshMu.Unlock()
+			return
+		}
+		w.refreshPending = false
+		w.refreshMu.Unlock()
+	}
+}
+
+func (w *window) refresh() {
 	text, err := w.getText()
 	if err != nil {
 		log.Printf("warning: failed to get text: %v", err)
+		w.showError(newSourceError(err, ""))
 		return
 	}
-	glib.IdleAdd(func() {
-		w.updateText(text)
-	})
+	if len(w.metrics) > 0 {
+		// metrics are already markup, with their values escaped
+		w.showText(text)
+		return
+	}
+	w.showSourceText(text)
+}
+
+// contentLine is a line of Pango markup, displayed in its own label with the
+// given CSS classes.
+type contentLine struct {
+	markup  string
+	classes []string
+}
+
+// contentLines splits text into lines according to the window's content
+// format.
+func (w *window) contentLines(text string) []contentLine {
+	if w.config.ContentFormat == texty.ContentFormatMarkdown {
+		return renderMarkdown(text)
+	}
+
+	// calendars are rendered by the window itself, while placeholders aren't
+	if w.config.Calendar != nil && (w.state == stateOk || w.state == stateStale) {
+		classes := calendarLineClasses(time.Now(), w.config.Calendar)
+		var lines []contentLine
+		for i, line := range strings.Split(text, "\n") {
+			content := contentLine{markup: line}
+			if i < len(classes) {
+				content.classes = classes[i]
+			}
+			lines = append(lines, content)
+		}
+		return lines
+	}
+
+	var lines []contentLine
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, contentLine{markup: line})
+	}
+	return lines
+}
+
+// markupLine returns the markup displaying line according to the window's
+// markup mode.
+func (w *window) markupLine(line string) string {
+	if w.config.Markup == nil {
+		return line
+	}
+	switch *w.config.Markup {
+	case texty.MarkupOff:
+		return glib.MarkupEscapeText(line)
+	case texty.MarkupAuto:
+		if err := validateMarkup(line); err != nil {
+			if warning := err.Error(); warning != w.markupWarning {
+				log.Printf("warning: invalid markup, displaying as plain text: %s", warning)
+				w.markupWarning = warning
+			}
+			return glib.MarkupEscapeText(line)
+		}
+	}
+	return line
 }
 
 func (w *window) updateText(text string) {
 	if w.closed {
 		return
 	}
+	w.text = text
 
-	lines := strings.Split(text, "\n")
+	// placeholders such as loading or error texts aren't tables
+	var rows [][]string
+	var lines []contentLine
+	if w.table != nil && (w.state == stateOk || w.state == stateStale) {
+		rows = w.table.split(text)
+		if w.state == stateStale && w.tableSuffix != "" {
+			lines = w.contentLines(w.tableSuffix)
+		}
+	} else {
+		lines = w.contentLines(text)
+	}
 	if len(lines) != 0 {
 		// remove empty lines from the beginning and end
-		for lines[0] == "" {
+		for len(lines) > 0 && lines[0].markup == "" {
 			lines = lines[1:]
 		}
-		for lines[len(lines)-1] == "" {
+		for len(lines) > 0 && lines[len(lines)-1].markup == "" {
 			lines = lines[:len(lines)-1]
 		}
 	}
 
+	if w.status != "" {
+		lines = append(lines, contentLine{markup: "<i>" + glib.MarkupEscapeText(w.status) + "</i>"})
+	}
+
 	spacing := 8
 	if w.config.Spacing != nil {
 		spacing = *w.config.Spacing
@@ -69,7 +196,11 @@ func (w *window) updateText(text string) {
 			item.(gtk.IWidget).ToWidget().Destroy()
 		})
 
-		for _, lineText := range lines {
+		if len(rows) > 0 {
+			w.addTable(rows, spacing)
+		}
+
+		for _, content := range lines {
 			line, err := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 0)
 			if err != nil {
 				log.Printf("warning: failed to create line: %v", err)
@@ -82,77 +213,109 @@ func (w *window) updateText(text string) {
 				log.Printf("warning: failed to create label: %v", err)
 				continue
 			}
-			label.SetMarkup(lineText)
+			label.SetMarkup(content.markup)
+			if len(content.classes) > 0 {
+				if styleContext, err := label.GetStyleContext(); err == nil {
+					for _, class := range content.classes {
+						styleContext.AddClass(class)
+					}
+				}
+			}
 			label.SetMarginBottom(spacing)
 			line.PackStart(label, true, true, 8)
-			if w.config.Position != nil && w.config.Position.Center && w.config.Align == nil {
-				label.SetHAlign(gtk.ALIGN_CENTER)
-				label.SetVAlign(gtk.ALIGN_CENTER)
-			} else if w.config.Align == nil {
-				label.SetHAlign(gtk.ALIGN_START)
-				label.SetVAlign(gtk.ALIGN_START)
-			} else {
-				label.SetHAlign(*w.config.Align)
-				label.SetVAlign(*w.config.Align)
-			}
+			align := w.contentAlign()
+			label.SetHAlign(align)
+			label.SetVAlign(align)
+			w.limitWidth(label, align)
 		}
 
+		// wrapped and ellipsized labels are limited to a number of
+		// characters, so this doesn't grow without bound
 		allocWidth := w.contentBox.GetAllocatedWidth()
 		if allocWidth > w.maxWidth {
 			w.maxWidth = allocWidth
 			w.window.SetSizeRequest(w.maxWidth, -1)
 		}
 
-		w.window.ShowAll()
+		w.contentBox.ShowAll()
+		if !w.hidden && !w.errorHidden {
+			w.window.ShowAll()
+		}
 
 		return false
 	})
 }
 
-func (w *window) jsonLoop() {
-
-	c := exec.Command(w.config.Command[0], w.config.Command[1:]...)
-	out, err := c.StdoutPipe()
-	if err != nil {
-		log.Printf("warning: failed to get stdout pipe: %v", err)
-		return
+// contentAlign returns the alignment of the window's content.
+func (w *window) contentAlign() gtk.Align {
+	if w.config.Align != nil {
+		return *w.config.Align
 	}
-	err = c.Start()
-	if err != nil {
-		log.Printf("warning: failed to start command: %v", err)
-		return
+	if w.config.Position != nil && w.config.Position.Center {
+		return gtk.ALIGN_CENTER
 	}
+	return gtk.ALIGN_START
+}
 
-	r := bufio.NewReader(out)
-	defer c.Wait()
+// limitWidth applies the window's wrap or ellipsize setting to label.
+func (w *window) limitWidth(label *gtk.Label, align gtk.Align) {
+	switch {
+	case w.config.Wrap != nil:
+		label.SetLineWrap(true)
+		label.SetLineWrapMode(w.config.Wrap.Mode)
+		label.SetMaxWidthChars(w.config.Wrap.Width)
+		// align the wrapped lines like the label itself
+		switch align {
+		case gtk.ALIGN_CENTER:
+			label.SetJustify(gtk.JUSTIFY_CENTER)
+		case gtk.ALIGN_END:
+			label.SetJustify(gtk.JUSTIFY_RIGHT)
+		}
+	case w.config.Ellipsize != nil:
+		label.SetEllipsize(w.config.Ellipsize.Mode)
+		label.SetMaxWidthChars(w.config.Ellipsize.MaxChars)
+	}
+}
 
-	c.Start()
+// readJson displays the output of a long-running command using the
+// format=json protocol.
+func (w *window) readJson(out io.Reader) error {
+	r := bufio.NewReader(out)
 	for {
 		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			log.Printf("warning: failed to read line: %v", err)
-			break
+			return fmt.Errorf("failed to read line: %w", err)
 		}
 		if len(bytes.TrimSpace(line)) == 0 {
 			continue
 		}
 
-		var jsonContent struct {
-			Text string `json:"text"`
-		}
-
-		if err := json.Unmarshal(line, &jsonContent); err != nil {
+		text, err := parseJsonText(line)
+		if err != nil {
 			log.Printf("warning: failed to unmarshal JSON: %v", err)
 			continue
 		}
 
 		if w.closed {
-			return
+			return errWindowClosed
 		}
 
-		glib.IdleAdd(func() {
-			w.updateText(jsonContent.Text)
-		})
+		w.showSourceText(text)
+	}
+}
+
+// parseJsonText extracts the text to display from a single line of output
+// using the format=json protocol.
+func parseJsonText(line []byte) (string, error) {
+	var jsonContent struct {
+		Text string `json:"text"`
 	}
 
+	if err := json.Unmarshal(line, &jsonContent); err != nil {
+		return "", err
+	}
+	return jsonContent.Text, nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"texty"
+)
+
+func TestMarkupLine(t *testing.T) {
+	mode := func(m texty.MarkupMode) *texty.MarkupMode { return &m }
+
+	tests := []struct {
+		mode *texty.MarkupMode
+		line string
+		want string
+	}{
+		{nil, "<b>bold</b>", "<b>bold</b>"},
+		{mode(texty.MarkupOn), "a < b", "a < b"},
+		{mode(texty.MarkupOff), "<b>a&b</b>", "&lt;b&gt;a&amp;b&lt;/b&gt;"},
+		{mode(texty.MarkupAuto), "<b>a &amp; b</b>", "<b>a &amp; b</b>"},
+		{mode(texty.MarkupAuto), "https://example.com/?a=1&b=2", "https://example.com/?a=1&amp;b=2"},
+		{mode(texty.MarkupAuto), "error: expected '>' in <vector<int>", "error: expected &#39;&gt;&#39; in &lt;vector&lt;int&gt;"},
+	}
+	for _, tt := range tests {
+		w := &window{config: &texty.Window{Markup: tt.mode}}
+		if got := w.markupLine(tt.line); got != tt.want {
+			t.Errorf("markupLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSourceText(t *testing.T) {
+	off := texty.MarkupOff
+	w := &window{config: &texty.Window{Markup: &off}}
+	if got, want := w.sourceText("a & b\n<c>", false), "a &amp; b\n&lt;c&gt;"; got != want {
+		t.Errorf("sourceText() = %q, want %q", got, want)
+	}
+
+	// the warning is recorded, so it is only logged once
+	auto := texty.MarkupAuto
+	w = &window{config: &texty.Window{Markup: &auto}}
+	w.sourceText("a & b", false)
+	if w.markupWarning == "" {
+		t.Error("no warning recorded for invalid markup")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"texty"
+)
+
+// maximum size of a single message written to a fifo
+const fifoMaxMessageSize = 1024 * 1024
+
+func (w *window) fifoLoop() {
+	path := *w.config.Fifo
+	if err := ensureFifo(path); err != nil {
+		log.Printf("warning: failed to create fifo: %v", err)
+		return
+	}
+
+	// opening the fifo for both reading and writing keeps it open across
+	// writers; otherwise reads would hit EOF as soon as the first writer
+	// closes its end
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		log.Printf("warning: failed to open fifo: %v", err)
+		return
+	}
+	defer f.Close()
+
+	splitter := &fifoSplitter{}
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 4096), fifoMaxMessageSize)
+	s.Split(splitter.split)
+
+	for s.Scan() {
+		msg := s.Bytes()
+
+		if splitter.tooLong {
+			splitter.tooLong = false
+			if !w.closed {
+				w.showError(newSourceError(fmt.Errorf("fifo message longer than %d bytes", fifoMaxMessageSize), ""))
+			}
+			continue
+		}
+
+		text := string(msg)
+		if w.config.FifoFormat == texty.CommandFormatJson {
+			if len(bytes.TrimSpace(msg)) == 0 {
+				continue
+			}
+			text, err = parseJsonText(msg)
+			if err != nil {
+				log.Printf("warning: failed to unmarshal JSON: %v", err)
+				continue
+			}
+		}
+
+		if w.closed {
+			return
+		}
+
+		w.showSourceText(text)
+	}
+
+	if err := s.Err(); err != nil {
+		log.Printf("warning: failed to read fifo: %v", err)
+		w.showError(newSourceError(err, ""))
+	}
+}
+
+// fifoSplitter splits fifo messages like scanMessages, but skips messages
+// longer than fifoMaxMessageSize up to their delimiter instead of failing, so
+// that the fifo keeps being read and writers don't block.
+type fifoSplitter struct {
+	// set while the rest of a message that is too long is skipped
+	skipping bool
+	// set with the empty token returned when a message is too long
+	tooLong bool
+}
+
+func (s *fifoSplitter) split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if s.skipping {
+		i := bytes.IndexAny(data, "\n\x00")
+		if i < 0 {
+			return len(data), nil, nil
+		}
+		s.skipping = false
+		return i + 1, nil, nil
+	}
+
+	advance, token, err = scanMessages(data, atEOF)
+	if advance == 0 && token == nil && len(data) >= fifoMaxMessageSize {
+		s.skipping = true
+		s.tooLong = true
+		return len(data), []byte{}, nil
+	}
+	return advance, token, err
+}
+
+// ensureFifo creates a named pipe at path if nothing exists there yet, and
+// makes sure that an existing file is a named pipe.
+func ensureFifo(path string) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return fmt.Errorf("%s exists and is not a fifo", path)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		return fmt.Errorf("mkfifo %s: %w", path, err)
+	}
+	return nil
+}
+
+// scanMessages is a bufio.SplitFunc that splits messages on newlines or NUL
+// bytes, whichever comes first.
+func scanMessages(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\n\x00"); i >= 0 {
+		return i + 1, bytes.TrimSuffix(data[:i], []byte("\r")), nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
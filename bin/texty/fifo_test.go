@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestFifoSplitter(t *testing.T) {
+	long := strings.Repeat("x", fifoMaxMessageSize+10)
+	splitter := &fifoSplitter{}
+	s := bufio.NewScanner(strings.NewReader("a\n" + long + "\nb\x00c"))
+	s.Buffer(make([]byte, 0, 4096), fifoMaxMessageSize)
+	s.Split(splitter.split)
+
+	var got []string
+	for s.Scan() {
+		if splitter.tooLong {
+			splitter.tooLong = false
+			got = append(got, "<too long>")
+			continue
+		}
+		got = append(got, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := "a|<too long>|b|c"
+	if strings.Join(got, "|") != want {
+		t.Errorf("messages = %q, want %q", strings.Join(got, "|"), want)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// colors used by i3bar for urgent blocks when the bar config doesn't
+// override them
+const (
+	i3barUrgentColor      = "#ffffff"
+	i3barUrgentBackground = "#900000"
+)
+
+type i3barHeader struct {
+	Version int `json:"version"`
+}
+
+type i3barBlock struct {
+	FullText            string `json:"full_text"`
+	Color               string `json:"color"`
+	Background          string `json:"background"`
+	Separator           *bool  `json:"separator"`
+	SeparatorBlockWidth *int   `json:"separator_block_width"`
+	Urgent              bool   `json:"urgent"`
+	Markup              string `json:"markup"`
+}
+
+// readI3bar displays the output of a long-running command speaking the i3bar
+// protocol.
+func (w *window) readI3bar(out io.Reader) error {
+	dec := json.NewDecoder(out)
+
+	var header i3barHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("failed to read i3bar header: %w", err)
+	}
+	if header.Version != 1 {
+		log.Printf("warning: unsupported i3bar protocol version %d", header.Version)
+	}
+
+	// the body is an infinite array of status lines, each of which is an
+	// array of blocks
+	if tok, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to read i3bar body: %w", err)
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("invalid i3bar body: expected '[', got %v", tok)
+	}
+
+	for dec.More() {
+		var blocks []i3barBlock
+		if err := dec.Decode(&blocks); err != nil {
+			return fmt.Errorf("failed to read i3bar status line: %w", err)
+		}
+
+		if w.closed {
+			return errWindowClosed
+		}
+
+		text := renderI3barBlocks(blocks)
+		w.showText(text)
+	}
+
+	// the command ended its output without closing the body
+	return nil
+}
+
+// renderI3barBlocks converts a status line into Pango markup, rendering each
+// block as a span with its colors applied.
+func renderI3barBlocks(blocks []i3barBlock) string {
+	var b strings.Builder
+	pendingSeparator := ""
+
+	for _, block := range blocks {
+		// i3bar doesn't draw empty blocks or their separators
+		if block.FullText == "" {
+			continue
+		}
+
+		b.WriteString(pendingSeparator)
+
+		text := block.FullText
+		if block.Markup != "pango" {
+			text = glib.MarkupEscapeText(text)
+		}
+
+		color, background := block.Color, block.Background
+		if block.Urgent {
+			color, background = i3barUrgentColor, i3barUrgentBackground
+		}
+
+		var attrs strings.Builder
+		if color != "" {
+			fmt.Fprintf(&attrs, ` foreground="%s"`, glib.MarkupEscapeText(color))
+		}
+		if background != "" {
+			fmt.Fprintf(&attrs, ` background="%s"`, glib.MarkupEscapeText(background))
+		}
+		if block.Urgent {
+			attrs.WriteString(` weight="bold"`)
+		}
+
+		if attrs.Len() > 0 {
+			fmt.Fprintf(&b, "<span%s>%s</span>", attrs.String(), text)
+		} else {
+			b.WriteString(text)
+		}
+
+		switch {
+		case block.Separator == nil || *block.Separator:
+			pendingSeparator = " | "
+		case block.SeparatorBlockWidth != nil && *block.SeparatorBlockWidth == 0:
+			pendingSeparator = ""
+		default:
+			pendingSeparator = " "
+		}
+	}
+
+	return b.String()
+}
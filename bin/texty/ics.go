@@ -0,0 +1,677 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsEvent is a VEVENT from an iCalendar file (RFC 5545).
+type icsEvent struct {
+	uid      string
+	summary  string
+	location string
+	start    time.Time
+	// exclusive; for all-day events, midnight after the last day
+	end      time.Time
+	duration *icsDuration
+	allDay   bool
+	rule     *recurrenceRule
+	exdates  []time.Time
+	// set if this event replaces a single occurrence of a recurring event
+	recurrenceId time.Time
+	cancelled    bool
+}
+
+// icsOccurrence is a single occurrence of an event.
+type icsOccurrence struct {
+	event *icsEvent
+	start time.Time
+	end   time.Time
+}
+
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseIcs returns the events of an iCalendar stream. Time zones are taken
+// from the IANA time zone database by their TZID, so VTIMEZONE definitions
+// are ignored.
+func parseIcs(r io.Reader) ([]*icsEvent, error) {
+	var events []*icsEvent
+	var event *icsEvent
+	// components enclosing the current line, e.g. VCALENDAR, VEVENT, VALARM
+	var components []string
+
+	lines, err := unfoldIcsLines(r)
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseIcsLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		switch p.name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(p.value))
+			if len(components) == 2 && components[1] == "VEVENT" {
+				event = &icsEvent{}
+			}
+			continue
+		case "END":
+			if len(components) == 2 && components[1] == "VEVENT" && event != nil {
+				if event.duration != nil {
+					// DURATION may come before DTSTART
+					event.end = event.duration.addTo(event.start)
+				}
+				if event.end.IsZero() {
+					if event.allDay {
+						event.end = event.start.AddDate(0, 0, 1)
+					} else {
+						event.end = event.start
+					}
+				}
+				if !event.start.IsZero() {
+					events = append(events, event)
+				}
+				event = nil
+			}
+			if len(components) > 0 {
+				components = components[:len(components)-1]
+			}
+			continue
+		}
+
+		// properties of nested components such as VALARM are ignored
+		if event == nil || len(components) != 2 {
+			continue
+		}
+		if err := event.setProperty(p); err != nil {
+			if p.name == "RRULE" {
+				// expanding a rule partially would show the event on the
+				// wrong days, but the other events can still be displayed
+				log.Printf("warning: skipping event at line %d: %s: %v", i+1, p.name, err)
+				event = nil
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %s: %v", i+1, p.name, err)
+		}
+	}
+	return events, nil
+}
+
+func (e *icsEvent) setProperty(p icsProperty) error {
+	var err error
+	switch p.name {
+	case "UID":
+		e.uid = p.value
+	case "SUMMARY":
+		e.summary = unescapeIcsText(p.value)
+	case "LOCATION":
+		e.location = unescapeIcsText(p.value)
+	case "STATUS":
+		e.cancelled = strings.EqualFold(p.value, "CANCELLED")
+	case "DTSTART":
+		e.start, e.allDay, err = parseIcsTime(p.value, p.params)
+	case "DTEND":
+		e.end, _, err = parseIcsTime(p.value, p.params)
+	case "DURATION":
+		var d icsDuration
+		if d, err = parseIcsDuration(p.value); err == nil {
+			e.duration = &d
+		}
+	case "RRULE":
+		e.rule, err = parseRecurrenceRule(p.value, p.params)
+	case "EXDATE":
+		for _, value := range strings.Split(p.value, ",") {
+			var t time.Time
+			if t, _, err = parseIcsTime(value, p.params); err != nil {
+				break
+			}
+			e.exdates = append(e.exdates, t)
+		}
+	case "RECURRENCE-ID":
+		e.recurrenceId, _, err = parseIcsTime(p.value, p.params)
+	}
+	return err
+}
+
+// unfoldIcsLines splits r into content lines, joining lines that were folded
+// by starting continuation lines with whitespace.
+func unfoldIcsLines(r io.Reader) ([]string, error) {
+	var lines []string
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, s.Err()
+}
+
+// parseIcsLine parses a content line such as
+// `DTSTART;TZID=Europe/Berlin:20261019T090000`.
+func parseIcsLine(line string) (icsProperty, error) {
+	p := icsProperty{params: make(map[string]string)}
+
+	// the value starts at the first colon outside of quoted parameter values
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("missing value")
+	}
+	p.value = line[colon+1:]
+
+	parts := splitIcsParams(line[:colon])
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return p, nil
+}
+
+// splitIcsParams splits a property name and its parameters at semicolons
+// outside of quotes.
+func splitIcsParams(s string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescapeIcsText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseIcsTime parses a DATE or DATE-TIME value. Dates are returned as
+// midnight in the local time zone, and times without a time zone are taken
+// to be local.
+func parseIcsTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, icsLocation(params["TZID"]))
+	return t, false, err
+}
+
+// icsLocation returns the time zone named by a TZID. Some clients prefix the
+// IANA name, e.g. `/mozilla.org/20050126_1/Europe/Berlin`, so shorter
+// suffixes are tried as well. Unknown time zones are taken to be local.
+func icsLocation(tzid string) *time.Location {
+	if tzid == "" {
+		return time.Local
+	}
+	for {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return loc
+		}
+		_, rest, ok := strings.Cut(tzid, "/")
+		if !ok {
+			return time.Local
+		}
+		tzid = rest
+	}
+}
+
+// icsDuration is a DURATION value. Days and weeks are nominal, so they're
+// added as calendar days rather than 24 hours.
+type icsDuration struct {
+	days int
+	time time.Duration
+}
+
+func (d icsDuration) addTo(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.time)
+}
+
+// parseIcsDuration parses a duration such as `PT1H30M`, `P1D` or `-P2W`.
+func parseIcsDuration(value string) (icsDuration, error) {
+	var d icsDuration
+	s := value
+	sign := 1
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return d, fmt.Errorf("invalid duration: %s", value)
+	}
+	s = s[1:]
+
+	inTime := false
+	n := ""
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			n += string(c)
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			return d, fmt.Errorf("invalid duration: %s", value)
+		}
+		n = ""
+		switch {
+		case c == 'W' && !inTime:
+			d.days += 7 * i
+		case c == 'D' && !inTime:
+			d.days += i
+		case c == 'H' && inTime:
+			d.time += time.Duration(i) * time.Hour
+		case c == 'M' && inTime:
+			d.time += time.Duration(i) * time.Minute
+		case c == 'S' && inTime:
+			d.time += time.Duration(i) * time.Second
+		default:
+			return d, fmt.Errorf("invalid duration: %s", value)
+		}
+	}
+	if n != "" {
+		return d, fmt.Errorf("invalid duration: %s", value)
+	}
+
+	d.days *= sign
+	d.time *= time.Duration(sign)
+	return d, nil
+}
+
+// recurrenceRule is an RRULE. Rules with other parts than the ones below,
+// such as BYWEEKNO, BYYEARDAY or the parts that select within a day (BYHOUR,
+// BYMINUTE, ...), are rejected rather than expanded to the wrong days.
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	bySetPos   []int
+	weekStart  time.Weekday
+}
+
+// weekdayNum is a BYDAY value such as `MO` or `-1FR`, the last Friday. n is
+// zero if every such weekday is meant.
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// upper bound on the number of periods a rule is expanded for, in case a rule
+// never produces an occurrence
+const maxRecurrencePeriods = 100000
+
+func parseRecurrenceRule(value string, params map[string]string) (*recurrenceRule, error) {
+	r := &recurrenceRule{interval: 1, weekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+			if err == nil && r.interval <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			r.until, _, err = parseIcsTime(val, params)
+		case "WKST":
+			day, ok := icsWeekdays[strings.ToUpper(val)]
+			if !ok {
+				err = fmt.Errorf("invalid weekday")
+			}
+			r.weekStart = day
+		case "BYDAY":
+			for _, s := range strings.Split(val, ",") {
+				s = strings.ToUpper(s)
+				if len(s) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY: %s", val)
+				}
+				day, ok := icsWeekdays[s[len(s)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY: %s", val)
+				}
+				wd := weekdayNum{day: day}
+				if prefix := s[:len(s)-2]; prefix != "" {
+					if wd.n, err = strconv.Atoi(prefix); err != nil {
+						return nil, fmt.Errorf("invalid BYDAY: %s", val)
+					}
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, s := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid BYMONTHDAY: %s", val)
+				}
+				r.byMonthDay = append(r.byMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, s := range strings.Split(val, ",") {
+				month, err := strconv.Atoi(s)
+				if err != nil || month < 1 || month > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH: %s", val)
+				}
+				r.byMonth = append(r.byMonth, time.Month(month))
+			}
+		case "BYSETPOS":
+			for _, s := range strings.Split(val, ",") {
+				pos, err := strconv.Atoi(s)
+				if err != nil || pos == 0 || pos < -366 || pos > 366 {
+					return nil, fmt.Errorf("invalid BYSETPOS: %s", val)
+				}
+				r.bySetPos = append(r.bySetPos, pos)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part: %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", key, val)
+		}
+	}
+
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported frequency: %s", r.freq)
+	}
+	if r.freq == "YEARLY" && len(r.byMonth) == 0 &&
+		slices.ContainsFunc(r.byDay, func(wd weekdayNum) bool { return wd.n != 0 }) {
+		// e.g. 20MO, the 20th Monday of the year
+		return nil, fmt.Errorf("unsupported BYDAY without BYMONTH in a yearly rule")
+	}
+	return r, nil
+}
+
+// expand returns the start times of the occurrences of the rule for an event
+// starting at start, up to and including end.
+func (r *recurrenceRule) expand(start, end time.Time) []time.Time {
+	var times []time.Time
+	n := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		candidates, periodStart := r.period(start, period)
+		if periodStart.After(end) {
+			break
+		}
+		for _, t := range candidates {
+			if t.Before(start) {
+				continue
+			}
+			if t.After(end) || (!r.until.IsZero() && t.After(r.until)) {
+				return times
+			}
+			times = append(times, t)
+			n++
+			if r.count > 0 && n >= r.count {
+				return times
+			}
+		}
+	}
+	return times
+}
+
+// period returns the sorted candidate times of the nth period (day, week,
+// month or year) of the rule, and the start of that period.
+func (r *recurrenceRule) period(start time.Time, n int) ([]time.Time, time.Time) {
+	hour, minute, second := start.Clock()
+	loc := start.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, loc)
+	}
+	year, month, day := start.Date()
+
+	var candidates []time.Time
+	var periodStart time.Time
+	switch r.freq {
+	case "DAILY":
+		t := at(year, month, day+n*r.interval)
+		periodStart = t
+		if r.matchesDay(t) {
+			candidates = append(candidates, t)
+		}
+	case "WEEKLY":
+		offset := (int(start.Weekday()) - int(r.weekStart) + 7) % 7
+		weekStart := at(year, month, day-offset+7*n*r.interval)
+		periodStart = weekStart
+		if len(r.byDay) == 0 {
+			candidates = append(candidates, weekStart.AddDate(0, 0, offset))
+		}
+		for _, wd := range r.byDay {
+			candidates = append(candidates, weekStart.AddDate(0, 0, (int(wd.day)-int(r.weekStart)+7)%7))
+		}
+		candidates = slices.DeleteFunc(candidates, func(t time.Time) bool {
+			return len(r.byMonth) > 0 && !slices.Contains(r.byMonth, t.Month())
+		})
+	case "MONTHLY":
+		first := at(year, month+time.Month(n*r.interval), 1)
+		periodStart = first
+		if len(r.byMonth) == 0 || slices.Contains(r.byMonth, first.Month()) {
+			candidates = r.monthDays(first, day)
+		}
+	case "YEARLY":
+		periodStart = at(year+n*r.interval, time.January, 1)
+		months := r.byMonth
+		switch {
+		case len(months) > 0:
+		case len(r.byDay) > 0 || len(r.byMonthDay) > 0:
+			// the days are selected across the whole year
+			for m := time.January; m <= time.December; m++ {
+				months = append(months, m)
+			}
+		default:
+			months = []time.Month{month}
+		}
+		for _, m := range months {
+			candidates = append(candidates, r.monthDays(at(year+n*r.interval, m, 1), day)...)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b time.Time) int { return a.Compare(b) })
+	candidates = slices.CompactFunc(candidates, time.Time.Equal)
+	return r.selectSetPos(candidates), periodStart
+}
+
+// selectSetPos returns the candidates of a period at the positions of the
+// rule's BYSETPOS part, e.g. -1 for the last one, or all of them if it has
+// none.
+func (r *recurrenceRule) selectSetPos(candidates []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return candidates
+	}
+	var selected []time.Time
+	for _, pos := range r.bySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(candidates) + pos
+		}
+		if i >= 0 && i < len(candidates) {
+			selected = append(selected, candidates[i])
+		}
+	}
+	slices.SortFunc(selected, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(selected, time.Time.Equal)
+}
+
+// matchesDay reports whether t satisfies the BY* parts of a daily rule.
+func (r *recurrenceRule) matchesDay(t time.Time) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, t.Month()) {
+		return false
+	}
+	if len(r.byMonthDay) > 0 && !slices.Contains(r.byMonthDay, t.Day()) {
+		return false
+	}
+	if len(r.byDay) > 0 && !slices.ContainsFunc(r.byDay, func(wd weekdayNum) bool { return wd.day == t.Weekday() }) {
+		return false
+	}
+	return true
+}
+
+// monthDays returns the days of the month starting at first selected by the
+// rule's BYDAY and BYMONTHDAY parts, or the day of the month of the event's
+// start if the rule has neither.
+func (r *recurrenceRule) monthDays(first time.Time, startDay int) []time.Time {
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	day := func(d int) time.Time {
+		return first.AddDate(0, 0, d-1)
+	}
+
+	var days []time.Time
+	if len(r.byMonthDay) > 0 {
+		for _, d := range r.byMonthDay {
+			if d < 0 {
+				d = daysInMonth + d + 1
+			}
+			if d >= 1 && d <= daysInMonth {
+				days = append(days, day(d))
+			}
+		}
+		if len(r.byDay) > 0 {
+			// both parts must match
+			days = slices.DeleteFunc(days, func(t time.Time) bool {
+				return !slices.ContainsFunc(r.byDay, func(wd weekdayNum) bool { return wd.day == t.Weekday() })
+			})
+		}
+		return days
+	}
+
+	if len(r.byDay) > 0 {
+		for _, wd := range r.byDay {
+			var matches []time.Time
+			for d := 1; d <= daysInMonth; d++ {
+				if t := day(d); t.Weekday() == wd.day {
+					matches = append(matches, t)
+				}
+			}
+			switch {
+			case wd.n == 0:
+				days = append(days, matches...)
+			case wd.n > 0 && wd.n <= len(matches):
+				days = append(days, matches[wd.n-1])
+			case wd.n < 0 && -wd.n <= len(matches):
+				days = append(days, matches[len(matches)+wd.n])
+			}
+		}
+		return days
+	}
+
+	if startDay <= daysInMonth {
+		days = append(days, day(startDay))
+	}
+	return days
+}
+
+// occurrences returns the occurrences of the events that overlap the range
+// from start to end, sorted by start time. Cancelled events and occurrences
+// replaced by other events are left out.
+func occurrences(events []*icsEvent, start, end time.Time) []icsOccurrence {
+	// occurrences of recurring events replaced by another event, by UID
+	replaced := make(map[string][]time.Time)
+	for _, e := range events {
+		if !e.recurrenceId.IsZero() {
+			replaced[e.uid] = append(replaced[e.uid], e.recurrenceId)
+		}
+	}
+
+	var result []icsOccurrence
+	for _, e := range events {
+		if e.cancelled {
+			continue
+		}
+
+		starts := []time.Time{e.start}
+		if e.rule != nil && e.recurrenceId.IsZero() {
+			starts = e.rule.expand(e.start, end)
+		}
+
+		for _, s := range starts {
+			excluded := func(t time.Time) bool { return t.Equal(s) }
+			if slices.ContainsFunc(e.exdates, excluded) ||
+				(e.recurrenceId.IsZero() && slices.ContainsFunc(replaced[e.uid], excluded)) {
+				continue
+			}
+
+			o := icsOccurrence{event: e, start: s}
+			if e.allDay {
+				// all-day events last whole days, even across DST changes
+				o.end = s.AddDate(0, 0, int(e.end.Sub(e.start).Round(24*time.Hour)/(24*time.Hour)))
+			} else {
+				o.end = s.Add(e.end.Sub(e.start))
+			}
+			// events without a duration are kept until they start
+			ongoing := o.end.After(start) || !o.start.Before(start)
+			if ongoing && o.start.Before(end) {
+				result = append(result, o)
+			}
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b icsOccurrence) int { return a.start.Compare(b.start) })
+	return result
+}
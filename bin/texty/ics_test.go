@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Stand-up\\, daily\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261001T093000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR\r\n" +
+	"EXDATE;TZID=Europe/Berlin:20261021T093000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT5M\r\n" +
+	"SUMMARY:ignored\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Berlin:20261023T093000\r\n" +
+	"SUMMARY:Stand-up (moved)\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261023T110000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20261023T111500\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Long\r\n" +
+	"  weekend\r\n" +
+	"DTSTART;VALUE=DATE:20261022\r\n" +
+	"DTEND;VALUE=DATE:20261024\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review\r\n" +
+	"SUMMARY:Review\r\n" +
+	"DTSTART:20261020T130000Z\r\n" +
+	"DTEND:20261020T140000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseIcs(t *testing.T) {
+	events, err := parseIcs(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+
+	standup := events[0]
+	if standup.summary != "Stand-up, daily" {
+		t.Errorf("summary = %q, want %q", standup.summary, "Stand-up, daily")
+	}
+	if d := standup.end.Sub(standup.start); d != 15*time.Minute {
+		t.Errorf("duration = %v, want 15m", d)
+	}
+	if standup.start.Location().String() != "Europe/Berlin" {
+		t.Errorf("location = %v, want Europe/Berlin", standup.start.Location())
+	}
+
+	holiday := events[2]
+	if !holiday.allDay || holiday.summary != "Long weekend" {
+		t.Errorf("holiday = %+v, want all-day event named %q", holiday, "Long weekend")
+	}
+	if !events[3].cancelled {
+		t.Error("review should be cancelled")
+	}
+}
+
+func TestParseIcsUnsupportedRule(t *testing.T) {
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:weekly\r\n" +
+		"DTSTART:20261020T130000Z\r\n" +
+		"RRULE:FREQ=YEARLY;BYWEEKNO=20\r\n" +
+		"SUMMARY:Skipped\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:once\r\n" +
+		"DTSTART:20261021T130000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	events, err := parseIcs(strings.NewReader(calendar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].uid != "once" {
+		t.Errorf("got %d events, want only once", len(events))
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	events, err := parseIcs(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2026, time.October, 19, 10, 0, 0, 0, berlin)
+	end := start.AddDate(0, 0, 7)
+
+	var got []string
+	for _, o := range occurrences(events, start, end) {
+		got = append(got, o.start.Format("Mon 02 15:04 ")+o.event.summary)
+	}
+	want := []string{
+		// Monday's stand-up is over, Wednesday's is excluded and Friday's is
+		// moved
+		"Thu 22 00:00 Long weekend",
+		"Fri 23 11:00 Stand-up (moved)",
+		// after the switch to winter time, still at 9:30 local time
+		"Mon 26 09:30 Stand-up, daily",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("occurrences =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRecurrenceRule(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 8, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", []string{"2026-01-31", "2026-02-02", "2026-02-04"}},
+		{"FREQ=WEEKLY;UNTIL=20260214T080000Z", []string{"2026-01-31", "2026-02-07", "2026-02-14"}},
+		// months without a 31st are skipped
+		{"FREQ=MONTHLY;COUNT=3", []string{"2026-01-31", "2026-03-31", "2026-05-31"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3", []string{"2026-01-31", "2026-02-28", "2026-03-31"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=2", []string{"2026-02-27", "2026-03-27"}},
+		{"FREQ=YEARLY;BYMONTH=5;BYDAY=2SU;COUNT=1", []string{"2026-05-10"}},
+		// the last weekday of the month
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=3", []string{"2026-02-27", "2026-03-31", "2026-04-30"}},
+		{"FREQ=MONTHLY;BYDAY=SA,SU;BYSETPOS=1,2;COUNT=4", []string{"2026-02-01", "2026-02-07", "2026-03-01", "2026-03-07"}},
+		// without BYMONTH, the days are selected across the whole year
+		{"FREQ=YEARLY;BYMONTHDAY=1;COUNT=3", []string{"2026-02-01", "2026-03-01", "2026-04-01"}},
+		{"FREQ=YEARLY;BYDAY=MO;COUNT=2", []string{"2026-02-02", "2026-02-09"}},
+	}
+	for _, tt := range tests {
+		r, err := parseRecurrenceRule(tt.rule, nil)
+		if err != nil {
+			t.Errorf("%s: %v", tt.rule, err)
+			continue
+		}
+		var got []string
+		for _, s := range r.expand(start, end) {
+			got = append(got, s.Format(time.DateOnly))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: got %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceRuleUnsupported(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=YEARLY;BYWEEKNO=20",
+		"FREQ=YEARLY;BYYEARDAY=100",
+		"FREQ=DAILY;BYHOUR=9,17",
+		"FREQ=WEEKLY;BYMINUTE=30",
+		"FREQ=YEARLY;BYDAY=20MO",
+		"FREQ=MONTHLY;BYDAY=MO;BYSETPOS=0",
+	} {
+		if _, err := parseRecurrenceRule(rule, nil); err == nil {
+			t.Errorf("%s: expected error", rule)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 14, 0, 0, 0, time.UTC)
+	timed := &icsEvent{}
+	allDay := &icsEvent{allDay: true}
+
+	tests := []struct {
+		event *icsEvent
+		start time.Time
+		want  string
+	}{
+		{timed, now.Add(-10 * time.Minute), "now"},
+		{timed, now.Add(25 * time.Minute), "in 25 min"},
+		{timed, now.Add(30 * time.Second), "in 1 min"},
+		{timed, now.Add(3*time.Hour + 5*time.Minute), "in 3 h 5 min"},
+		{timed, time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC), "tomorrow 09:00"},
+		{timed, time.Date(2026, time.October, 21, 9, 0, 0, 0, time.UTC), "Wed 21 Oct 09:00"},
+		{allDay, time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC), "today"},
+		{allDay, time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC), "tomorrow"},
+		{allDay, time.Date(2026, time.October, 24, 0, 0, 0, 0, time.UTC), "Sat 24 Oct"},
+	}
+	for _, tt := range tests {
+		o := icsOccurrence{event: tt.event, start: tt.start}
+		if got := relativeTime(o, now, englishNames); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.start, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"texty"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// maximum size of a single IPC request
+const ipcMaxRequestSize = 1024 * 1024
+
+// ipcRequest is a single command sent to a running instance over the IPC
+// socket. Requests and responses are newline-delimited JSON objects.
+type ipcRequest struct {
+	Command  string          `json:"command"`
+	Window   string          `json:"window,omitempty"`
+	Text     *string         `json:"text,omitempty"`
+	Position *texty.Position `json:"position,omitempty"`
+}
+
+type ipcResponse struct {
+	Ok      bool        `json:"ok"`
+	Error   string      `json:"error,omitempty"`
+	Windows []ipcWindow `json:"windows,omitempty"`
+}
+
+type ipcWindow struct {
+	Id       string `json:"id"`
+	Text     string `json:"text"`
+	Visible  bool   `json:"visible"`
+	Restarts int64  `json:"restarts,omitempty"`
+}
+
+type ipcServer struct {
+	listener net.Listener
+	windows  []*window
+	verbose  bool
+}
+
+func socketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("texty-%d.sock", os.Getuid()))
+	}
+	return filepath.Join(dir, "texty.sock")
+}
+
+func startIpcServer(windows []*window, verbose bool) *ipcServer {
+	path := socketPath()
+
+	if _, err := os.Stat(path); err == nil {
+		// a socket that still accepts connections belongs to another instance
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			log.Printf("warning: another instance is listening on %s, IPC disabled", path)
+			return nil
+		}
+		// otherwise, it was left behind by an instance that didn't exit cleanly
+		if err := os.Remove(path); err != nil {
+			log.Printf("warning: failed to remove stale socket: %v", err)
+			return nil
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		log.Printf("warning: failed to listen on %s: %v", path, err)
+		return nil
+	}
+
+	if verbose {
+		log.Printf("listening for IPC requests on %s", path)
+	}
+
+	s := &ipcServer{listener: l, windows: windows, verbose: verbose}
+	go s.serve()
+	return s
+}
+
+func (s *ipcServer) Close() error {
+	return s.listener.Close()
+}
+
+func (s *ipcServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("warning: failed to accept IPC connection: %v", err)
+			continue
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *ipcServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewScanner(conn)
+	r.Buffer(make([]byte, 0, 4096), ipcMaxRequestSize)
+	enc := json.NewEncoder(conn)
+
+	for r.Scan() {
+		var req ipcRequest
+		var res ipcResponse
+		if err := json.Unmarshal(r.Bytes(), &req); err != nil {
+			res = ipcResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			res = s.dispatch(req)
+		}
+
+		if err := enc.Encode(res); err != nil {
+			log.Printf("warning: failed to write IPC response: %v", err)
+			return
+		}
+
+		if req.Command == "reload" && res.Ok {
+			// only quit once the client has been answered
+			glib.IdleAdd(func() {
+				restart = true
+				gtk.MainQuit()
+			})
+			return
+		}
+	}
+}
+
+// dispatch runs a request on the GTK main loop and waits for its result.
+func (s *ipcServer) dispatch(req ipcRequest) ipcResponse {
+	done := make(chan ipcResponse, 1)
+	glib.IdleAdd(func() {
+		done <- s.handle(req)
+	})
+	return <-done
+}
+
+func (s *ipcServer) handle(req ipcRequest) ipcResponse {
+	if s.verbose {
+		log.Printf("IPC request: %s %s", req.Command, req.Window)
+	}
+
+	switch req.Command {
+	case "list":
+		res := ipcResponse{Ok: true, Windows: make([]ipcWindow, 0, len(s.windows))}
+		for _, w := range s.windows {
+			if w.closed {
+				continue
+			}
+			res.Windows = append(res.Windows, ipcWindow{
+				Id:       w.config.Id,
+				Text:     w.text,
+				Visible:  !w.hidden,
+				Restarts: w.restarts.Load(),
+			})
+		}
+		return res
+	case "reload":
+		return ipcResponse{Ok: true}
+	}
+
+	if req.Window == "" {
+		return ipcError("%s requires a window", req.Command)
+	}
+	w := s.find(req.Window)
+	if w == nil {
+		return ipcError("no such window: %s", req.Window)
+	}
+
+	switch req.Command {
+	case "set-text":
+		if req.Text == nil {
+			return ipcError("set-text requires text")
+		}
+		// text sent over IPC is markup, even if the window's command
+		// outputs ANSI escape sequences
+		w.setText(w.sourceText(*req.Text, false))
+	case "refresh":
+		if !w.refreshable() {
+			return ipcError("window %s updates itself and cannot be refreshed", w.config.Id)
+		}
+		go w.draw()
+	case "show":
+		w.setHidden(false)
+	case "hide":
+		w.setHidden(true)
+	case "toggle":
+		w.setHidden(!w.hidden)
+	case "move":
+		if req.Position == nil {
+			return ipcError("move requires a position")
+		}
+		if err := req.Position.Validate(); err != nil {
+			return ipcError("invalid position: %v", err)
+		}
+		w.config.Position = req.Position
+		w.position()
+	default:
+		return ipcError("unknown command: %s", req.Command)
+	}
+
+	return ipcResponse{Ok: true}
+}
+
+func (s *ipcServer) find(id string) *window {
+	for _, w := range s.windows {
+		if w.config.Id == id && !w.closed {
+			return w
+		}
+	}
+	return nil
+}
+
+func ipcError(format string, args ...any) ipcResponse {
+	return ipcResponse{Error: fmt.Sprintf(format, args...)}
+}
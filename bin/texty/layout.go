@@ -16,6 +16,17 @@ func (w *window) layout() {
 		layershell.SetLayer(w.window, layershell.LayerBottom)
 	}
 
+	w.position()
+}
+
+// position anchors the window according to its configured position. It can
+// be called again after the position changes to move the window.
+func (w *window) position() {
+	for _, edge := range []layershell.Edge{layershell.EdgeTop, layershell.EdgeBottom, layershell.EdgeLeft, layershell.EdgeRight} {
+		layershell.SetAnchor(w.window, edge, false)
+		layershell.SetMargin(w.window, edge, 0)
+	}
+
 	if w.config.Position != nil {
 		if w.config.Position.Top != nil {
 			layershell.SetAnchor(w.window, layershell.EdgeTop, true)
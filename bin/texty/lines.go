@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"texty"
+)
+
+// number of lines kept by format=append when max-lines isn't set
+const defaultMaxLines = 10
+
+// readLines displays the output of a long-running command line by line,
+// either replacing the text with each new line (format=lines) or keeping a
+// scrollback of the most recent lines (format=append).
+func (w *window) readLines(out io.Reader) error {
+	maxLines := defaultMaxLines
+	if w.config.MaxLines != nil {
+		maxLines = *w.config.MaxLines
+	}
+	newestOnTop := w.config.NewestOnTop != nil && *w.config.NewestOnTop
+
+	var scrollback []string
+	r := bufio.NewReader(out)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read line: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		text := line
+		if w.config.CommandFormat == texty.CommandFormatAppend {
+			scrollback = append(scrollback, line)
+			if len(scrollback) > maxLines {
+				scrollback = scrollback[len(scrollback)-maxLines:]
+			}
+			text = renderScrollback(scrollback, newestOnTop)
+		}
+
+		if w.closed {
+			return errWindowClosed
+		}
+
+		w.showSourceText(text)
+	}
+}
+
+func renderScrollback(lines []string, newestOnTop bool) string {
+	if newestOnTop {
+		lines = slices.Clone(lines)
+		slices.Reverse(lines)
+	}
+	return strings.Join(lines, "\n")
+}
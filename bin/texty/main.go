@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"syscall"
 	"texty"
 	"time"
@@ -27,6 +28,10 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "msg" {
+		os.Exit(msgMain(os.Args[2:]))
+	}
+
 	flag.CommandLine.Init("", flag.ExitOnError)
 	getopt.Parse()
 
@@ -44,6 +49,19 @@ func main() {
 
 	go watchConfig(configPath, verbose)
 
+	if config.ProcRoot != "" {
+		procRoot = config.ProcRoot
+	}
+	if config.SysRoot != "" {
+		sysRoot = config.SysRoot
+	}
+
+	if configPath != "" {
+		if dir, err := filepath.Abs(filepath.Dir(configPath)); err == nil {
+			configDir = dir
+		}
+	}
+
 	cssProvider, err := gtk.CssProviderNew()
 	if err != nil {
 		log.Printf("warning: failed to create CSS provider: %v", err)
@@ -84,9 +102,29 @@ func main() {
 		}
 		styleContext.AddProvider(cssProvider, gtk.STYLE_PROVIDER_PRIORITY_USER)
 
-		if w.config.CommandFormat == texty.CommandFormatJson {
-			go w.jsonLoop()
-		} else {
+		switch {
+		case w.config.Fifo != nil:
+			go w.fifoLoop()
+		case w.config.File != nil && w.config.FileFollow:
+			go w.followFile()
+		case len(w.config.Clocks) > 0:
+			go w.clockLoop()
+		case w.config.Calendar != nil:
+			go w.calendarLoop()
+		case w.config.Agenda != nil:
+			go w.agendaLoop()
+		case w.config.Countdown != nil:
+			go w.countdownLoop()
+		case w.config.Stopwatch != nil:
+			go w.stopwatchLoop()
+		case w.config.CommandFormat == texty.CommandFormatJson:
+			go w.supervise(w.readJson)
+		case w.config.CommandFormat == texty.CommandFormatI3bar:
+			go w.supervise(w.readI3bar)
+		case w.config.CommandFormat == texty.CommandFormatLines,
+			w.config.CommandFormat == texty.CommandFormatAppend:
+			go w.supervise(w.readLines)
+		default:
 			go w.draw()
 		}
 
@@ -96,7 +134,7 @@ func main() {
 	for _, w := range windows {
 		var h1 glib.SignalHandle
 		h1 = w.window.Connect("destroy", func() {
-			w.closed = true
+			w.markClosed()
 			allClosed := true
 			for _, w := range windows {
 				if !w.closed {
@@ -110,19 +148,32 @@ func main() {
 			w.window.HandlerDisconnect(h1)
 		})
 
-		// middle mouse button to close
+		// middle mouse button to close; stopwatches are started and paused
+		// with the left button and reset with the right button
 		var h2 glib.SignalHandle
 		h2 = w.window.Connect("button-press-event", func(_ *gtk.Window, e *gdk.Event) {
 			ev := gdk.EventButtonNewFromEvent(e)
-			if ev.Button() == gdk.BUTTON_MIDDLE {
+			switch {
+			case ev.Button() == gdk.BUTTON_MIDDLE:
 				w.window.Close()
-				w.closed = true
+				w.markClosed()
 				w.window.HandlerDisconnect(h2)
+			case ev.Button() == gdk.BUTTON_PRIMARY && w.stopwatch != nil:
+				w.stopwatch.toggle()
+			case ev.Button() == gdk.BUTTON_SECONDARY && w.stopwatch != nil:
+				w.stopwatch.reset()
 			}
 		})
 
-		if w.config.Interval != nil {
-			ms := time.Duration(*w.config.Interval) / time.Millisecond
+		if w.config.StaleAfter != nil {
+			glib.TimeoutAdd(staleCheckInterval, func() bool {
+				w.checkStale()
+				return !w.closed
+			})
+		}
+
+		if interval := w.interval(); interval > 0 {
+			ms := interval / time.Millisecond
 			glib.TimeoutAdd(uint(ms), func() bool {
 				go w.draw()
 				return !w.closed
@@ -130,9 +181,15 @@ func main() {
 		}
 	}
 
+	ipc := startIpcServer(windows, verbose)
+
 	log.Print("texty started")
 	gtk.Main()
 
+	if ipc != nil {
+		ipc.Close()
+	}
+
 	if restart {
 		exe, err := os.Executable()
 		if err != nil {
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// Markdown is rendered line by line: each line of the source becomes a line
+// of Pango markup, with CSS classes describing its block (heading, list item,
+// code block, ...) added to its label. Paragraphs aren't reflowed.
+
+var (
+	markdownHeading  = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	markdownRule     = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownFence    = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	markdownQuote    = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	markdownListItem = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+	markdownTask     = regexp.MustCompile(`^\[([ xX])\][ \t]+(.*)$`)
+	markdownEntity   = regexp.MustCompile(`^&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6});`)
+	markdownAutolink = regexp.MustCompile(`^<((?:https?|mailto):[^<>\s]*)>`)
+)
+
+// Pango attributes of headings by level
+var markdownHeadingAttrs = []string{
+	`size="xx-large" weight="bold"`,
+	`size="x-large" weight="bold"`,
+	`size="large" weight="bold"`,
+	`weight="bold"`,
+	`weight="bold"`,
+	`weight="bold"`,
+}
+
+// renderMarkdown converts Markdown to lines of Pango markup.
+func renderMarkdown(text string) []contentLine {
+	var lines []contentLine
+	fence := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				continue
+			}
+			lines = append(lines, contentLine{
+				markup:  "<tt>" + glib.MarkupEscapeText(line) + "</tt>",
+				classes: []string{"code-block"},
+			})
+			continue
+		}
+
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			continue
+		}
+
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			lines = append(lines, contentLine{
+				markup:  "<span " + markdownHeadingAttrs[level-1] + ">" + renderInline(m[2]) + "</span>",
+				classes: []string{"heading", "h" + strconv.Itoa(level)},
+			})
+			continue
+		}
+
+		if markdownRule.MatchString(line) {
+			lines = append(lines, contentLine{markup: strings.Repeat("─", 20), classes: []string{"rule"}})
+			continue
+		}
+
+		if m := markdownQuote.FindStringSubmatch(line); m != nil {
+			lines = append(lines, contentLine{
+				markup:  "<i>" + renderInline(m[1]) + "</i>",
+				classes: []string{"quote"},
+			})
+			continue
+		}
+
+		if m := markdownListItem.FindStringSubmatch(line); m != nil {
+			lines = append(lines, renderListItem(m[1], m[2], m[3]))
+			continue
+		}
+
+		lines = append(lines, contentLine{markup: renderInline(strings.TrimSpace(line))})
+	}
+	return lines
+}
+
+// renderListItem renders a list item, indented by its nesting level. Task
+// list items get a checkbox instead of a bullet.
+func renderListItem(indent, marker, text string) contentLine {
+	// two spaces per level, like most editors
+	level := len(strings.ReplaceAll(indent, "\t", "    ")) / 2
+	prefix := strings.Repeat("  ", level)
+	classes := []string{"list-item"}
+
+	if m := markdownTask.FindStringSubmatch(text); m != nil {
+		box := "☐"
+		classes = append(classes, "task")
+		if m[1] != " " {
+			box = "☑"
+			classes = append(classes, "task-done")
+		}
+		return contentLine{markup: prefix + box + " " + renderInline(m[2]), classes: classes}
+	}
+
+	bullet := "•"
+	if unicode.IsDigit(rune(marker[0])) {
+		bullet = glib.MarkupEscapeText(marker)
+	}
+	return contentLine{markup: prefix + bullet + " " + renderInline(text), classes: classes}
+}
+
+// inline delimiters and the Pango tags they're converted to, longest first
+var markdownInline = []struct {
+	delim string
+	tag   string
+}{
+	{"**", "b"},
+	{"__", "b"},
+	{"~~", "s"},
+	{"*", "i"},
+	{"_", "i"},
+}
+
+// renderInline converts inline Markdown (emphasis, code spans, links and
+// escapes) to Pango markup. The XML entities such as &amp;lt; are passed
+// through, so text that is already escaped is displayed as is, while other
+// entities, which Pango doesn't know, are decoded.
+func renderInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		// backslash escapes
+		if c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!~<>&|", s[i+1]) >= 0 {
+			b.WriteString(glib.MarkupEscapeText(s[i+1 : i+2]))
+			i += 2
+			continue
+		}
+
+		if c == '&' {
+			if m := markdownEntity.FindString(s[i:]); m != "" {
+				b.WriteString(markdownEntityMarkup(m))
+				i += len(m)
+				continue
+			}
+		}
+
+		// code spans, delimited by runs of the same number of backticks
+		if c == '`' {
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			delim := s[i : i+n]
+			if end := strings.Index(s[i+n:], delim); end >= 0 {
+				code := s[i+n : i+n+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<tt>" + glib.MarkupEscapeText(code) + "</tt>")
+				i += 2*n + end
+				continue
+			}
+			b.WriteString(delim)
+			i += n
+			continue
+		}
+
+		if c == '[' {
+			if text, url, n, ok := parseMarkdownLink(s[i:]); ok {
+				b.WriteString(`<a href="` + glib.MarkupEscapeText(url) + `">` + renderInline(text) + "</a>")
+				i += n
+				continue
+			}
+		}
+
+		if c == '<' {
+			if m := markdownAutolink.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="` + glib.MarkupEscapeText(m[1]) + `">` + glib.MarkupEscapeText(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+		}
+
+		if c == '*' || c == '_' || c == '~' {
+			if rendered, n, ok := renderEmphasis(s, i); ok {
+				b.WriteString(rendered)
+				i += n
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(glib.MarkupEscapeText(string(r)))
+		i += size
+	}
+	return b.String()
+}
+
+// renderEmphasis renders emphasis starting at s[i], returning the markup and
+// the number of bytes consumed.
+func renderEmphasis(s string, i int) (string, int, bool) {
+	for _, e := range markdownInline {
+		if !strings.HasPrefix(s[i:], e.delim) {
+			continue
+		}
+		start := i + len(e.delim)
+		// the delimiter must be followed by text, and underscores must not be
+		// inside a word, e.g. snake_case
+		if start >= len(s) || s[start] == ' ' {
+			continue
+		}
+		if e.delim[0] == '_' && i > 0 && isWordByte(s[i-1]) {
+			continue
+		}
+
+		for end := start + 1; end <= len(s)-len(e.delim); end++ {
+			if !strings.HasPrefix(s[end:], e.delim) || s[end-1] == ' ' {
+				continue
+			}
+			after := end + len(e.delim)
+			if e.delim[0] == '_' && after < len(s) && isWordByte(s[after]) {
+				continue
+			}
+			// single delimiters must not be part of a double one
+			if len(e.delim) == 1 && (s[end-1] == e.delim[0] || after < len(s) && s[after] == e.delim[0]) {
+				continue
+			}
+			inner := renderInline(s[start:end])
+			return "<" + e.tag + ">" + inner + "</" + e.tag + ">", after - i, true
+		}
+	}
+	return "", 0, false
+}
+
+// parseMarkdownLink parses a link such as `[text](url "title")` at the start
+// of s.
+func parseMarkdownLink(s string) (text, url string, n int, ok bool) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(s) && closing < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing < 0 || closing+1 >= len(s) || s[closing+1] != '(' {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[closing+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+
+	dest := strings.TrimSpace(s[closing+2 : closing+2+end])
+	// titles aren't displayed
+	if space := strings.IndexAny(dest, " \t"); space >= 0 {
+		dest = dest[:space]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	return s[1:closing], dest, closing + 3 + end, true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// markdownEntityMarkup returns the markup displaying an HTML entity or
+// character reference.
+func markdownEntityMarkup(entity string) string {
+	switch entity {
+	case "&lt;", "&gt;", "&amp;", "&quot;", "&apos;":
+		return entity
+	}
+	// unknown entities are displayed as is, and invalid character references
+	// such as &#0; as U+FFFD
+	return glib.MarkupEscapeText(html.UnescapeString(entity))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	input := "# Git *cheat* sheet\n" +
+		"\n" +
+		"Use `git status` **often**, see [the docs](https://git-scm.com \"Git\").\n" +
+		"- [ ] stage\n" +
+		"  - [x] commit\n" +
+		"1. push\n" +
+		"> quote\n" +
+		"---\n" +
+		"```sh\n" +
+		"git log --oneline\n" +
+		"```\n" +
+		"snake_case and \\*stars\\* and ~~old~~ &lt;tag&gt;"
+
+	want := []contentLine{
+		{`<span size="xx-large" weight="bold">Git <i>cheat</i> sheet</span>`, []string{"heading", "h1"}},
+		{"", nil},
+		{`Use <tt>git status</tt> <b>often</b>, see <a href="https://git-scm.com">the docs</a>.`, nil},
+		{"☐ stage", []string{"list-item", "task"}},
+		{"  ☑ commit", []string{"list-item", "task", "task-done"}},
+		{"1. push", []string{"list-item"}},
+		{"<i>quote</i>", []string{"quote"}},
+		{"────────────────────", []string{"rule"}},
+		{"<tt>git log --oneline</tt>", []string{"code-block"}},
+		{"snake_case and *stars* and <s>old</s> &lt;tag&gt;", nil},
+	}
+
+	got := renderMarkdown(input)
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].markup != want[i].markup || !slices.Equal(got[i].classes, want[i].classes) {
+			t.Errorf("line %d = %q %q, want %q %q", i, got[i].markup, got[i].classes, want[i].markup, want[i].classes)
+		}
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"*a **b** c*", "<i>a <b>b</b> c</i>"},
+		{"__bold__ _it_", "<b>bold</b> <i>it</i>"},
+		{"2 * 3 * 4", "2 * 3 * 4"},
+		{"``a ` b``", "<tt>a ` b</tt>"},
+		{"<https://example.com>", `<a href="https://example.com">https://example.com</a>`},
+		{"[unclosed", "[unclosed"},
+		{"a&nbsp;&mdash;&copy; &lt;&amp;&#65;&#x42; &foo; &#0;&#x110000;", "a\u00a0—© &lt;&amp;AB &amp;foo; \ufffd\ufffd"},
+	}
+	for _, tt := range tests {
+		if got := renderInline(tt.in); got != tt.want {
+			t.Errorf("renderInline(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
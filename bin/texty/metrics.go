@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"texty"
+	"time"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// how often built-in sources are updated when the window has no interval
+const defaultMetricInterval = 2 * time.Second
+
+// root of the proc filesystem, configurable with proc-root so that built-in
+// sources can be pointed at fixtures
+var procRoot = "/proc"
+
+// root of the sysfs filesystem, configurable with sys-root
+var sysRoot = "/sys"
+
+// metricSource is the runtime side of a built-in source. rows is called on
+// every update and returns the placeholders available to the source's format,
+// once for each line the source displays.
+type metricSource interface {
+	rows() ([]map[string]string, error)
+}
+
+// valuesSource is a built-in source that displays a single line.
+type valuesSource interface {
+	values() (map[string]string, error)
+}
+
+// singleRow adapts a valuesSource to a metricSource.
+type singleRow struct {
+	valuesSource
+}
+
+func (s singleRow) rows() ([]map[string]string, error) {
+	values, err := s.values()
+	if err != nil {
+		return nil, err
+	}
+	return []map[string]string{values}, nil
+}
+
+func newMetricSource(m *texty.Metric) (metricSource, error) {
+	switch m.Kind {
+	case "cpu":
+		return singleRow{&cpuSource{}}, nil
+	case "memory":
+		return singleRow{memorySource{}}, nil
+	case "load":
+		return singleRow{loadSource{}}, nil
+	case "uptime":
+		return singleRow{uptimeSource{}}, nil
+	case "network":
+		return singleRow{newNetworkSource(m)}, nil
+	case "disk":
+		return newDiskSource(m), nil
+	case "battery":
+		return singleRow{&powerSupplySource{name: m.Options["name"], supplyType: "Battery"}}, nil
+	case "ac":
+		return singleRow{&powerSupplySource{name: m.Options["name"], supplyType: "Mains"}}, nil
+	case "temperature":
+		return singleRow{&temperatureSource{zone: m.Options["zone"], hwmon: m.Options["hwmon"], label: m.Options["label"]}}, nil
+	case "backlight":
+		return singleRow{&backlightSource{device: m.Options["device"]}}, nil
+	case "processes":
+		return newProcessesSource(m), nil
+	}
+	return nil, fmt.Errorf("unknown source: %s", m.Kind)
+}
+
+// metricsText formats the values of each of the window's built-in sources,
+// one per line.
+func (w *window) metricsText() (string, error) {
+	lines := make([]string, 0, len(w.metrics))
+	for i, source := range w.metrics {
+		rows, err := source.rows()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", w.config.Metrics[i].Kind, err)
+		}
+		for _, values := range rows {
+			for k, v := range values {
+				values[k] = glib.MarkupEscapeText(v)
+			}
+			lines = append(lines, expandPlaceholders(w.config.Metrics[i].Format, values))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// formatBytes formats a size using binary units, e.g. "1.5 GiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatRate formats a rate in bytes per second, e.g. "1.5 MiB/s".
+func formatRate(bytesPerSecond float64) string {
+	return formatBytes(uint64(bytesPerSecond)) + "/s"
+}
+
+// formatDuration formats a duration with minute precision, e.g. "3d 4h 12m".
+func formatDuration(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d / time.Hour % 24)
+	minutes := int(d / time.Minute % 60)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func formatPercent(f float64) string {
+	return strconv.FormatFloat(f, 'f', 0, 64)
+}
+
+func percent(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
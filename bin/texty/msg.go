@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"texty"
+)
+
+const msgUsage = `usage: texty msg <command> [window] [args...]
+
+commands:
+  list                       list windows and their current text
+  reload                     reload the configuration
+  set-text <window> <text>   replace a window's text
+  refresh <window>           refresh a window's content immediately
+  show <window>              show a hidden window
+  hide <window>              hide a window
+  toggle <window>            toggle a window's visibility
+  move <window> [top=N] [bottom=N] [left=N] [right=N] [center]
+                             change a window's position
+`
+
+// msgMain implements the `texty msg` subcommand, which sends a single request
+// to a running instance and prints the result.
+func msgMain(args []string) int {
+	req, err := parseMsgArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "texty msg: %v\n\n%s", err, msgUsage)
+		return 2
+	}
+
+	path := socketPath()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "texty msg: failed to connect to %s (is texty running?): %v\n", path, err)
+		return 1
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		fmt.Fprintf(os.Stderr, "texty msg: failed to send request: %v\n", err)
+		return 1
+	}
+
+	var res ipcResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		fmt.Fprintf(os.Stderr, "texty msg: failed to read response: %v\n", err)
+		return 1
+	}
+
+	if !res.Ok {
+		fmt.Fprintf(os.Stderr, "texty msg: %s\n", res.Error)
+		return 1
+	}
+
+	if req.Command == "list" {
+		for _, w := range res.Windows {
+			header := w.Id
+			if !w.Visible {
+				header += " (hidden)"
+			}
+			if w.Restarts > 0 {
+				header += fmt.Sprintf(" (restarted %d times)", w.Restarts)
+			}
+			fmt.Println(header)
+			for _, line := range strings.Split(strings.TrimRight(w.Text, "\n"), "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	return 0
+}
+
+func parseMsgArgs(args []string) (ipcRequest, error) {
+	if len(args) == 0 {
+		return ipcRequest{}, errors.New("missing command")
+	}
+
+	req := ipcRequest{Command: args[0]}
+	args = args[1:]
+
+	switch req.Command {
+	case "list", "reload":
+		if len(args) != 0 {
+			return req, fmt.Errorf("%s takes no arguments", req.Command)
+		}
+		return req, nil
+	case "set-text", "refresh", "show", "hide", "toggle", "move":
+	default:
+		return req, fmt.Errorf("unknown command: %s", req.Command)
+	}
+
+	if len(args) == 0 {
+		return req, fmt.Errorf("%s requires a window", req.Command)
+	}
+	req.Window = args[0]
+	args = args[1:]
+
+	switch req.Command {
+	case "set-text":
+		if len(args) == 0 {
+			return req, errors.New("set-text requires text")
+		}
+		text := strings.Join(args, " ")
+		req.Text = &text
+	case "move":
+		if len(args) == 0 {
+			return req, errors.New("move requires a position")
+		}
+		position, err := parseMsgPosition(args)
+		if err != nil {
+			return req, err
+		}
+		req.Position = position
+	default:
+		if len(args) != 0 {
+			return req, fmt.Errorf("too many arguments for %s", req.Command)
+		}
+	}
+
+	return req, nil
+}
+
+func parseMsgPosition(args []string) (*texty.Position, error) {
+	p := new(texty.Position)
+	for _, arg := range args {
+		if arg == "center" {
+			p.Center = true
+			continue
+		}
+
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid position argument: %s", arg)
+		}
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %s", key, value)
+		}
+
+		switch key {
+		case "top":
+			p.Top = &i
+		case "bottom":
+			p.Bottom = &i
+		case "left":
+			p.Left = &i
+		case "right":
+			p.Right = &i
+		default:
+			return nil, fmt.Errorf("invalid position argument: %s", arg)
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
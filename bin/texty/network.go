@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"texty"
+	"time"
+)
+
+// networkSource reports the throughput, link state and addresses of a network
+// interface. With interface=auto (the default), the interface of the default
+// route is used.
+type networkSource struct {
+	iface string
+
+	prevIface string
+	prevRx    uint64
+	prevTx    uint64
+	prevTime  time.Time
+}
+
+func newNetworkSource(m *texty.Metric) *networkSource {
+	iface := m.Options["interface"]
+	if iface == "" {
+		iface = "auto"
+	}
+	return &networkSource{iface: iface}
+}
+
+func (s *networkSource) values() (map[string]string, error) {
+	iface := s.iface
+	if iface == "auto" {
+		var err error
+		iface, err = defaultRouteInterface()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	values := map[string]string{
+		"interface": iface,
+		"state":     "down",
+		"rx-rate":   formatRate(0),
+		"tx-rate":   formatRate(0),
+		"rx-total":  formatBytes(0),
+		"tx-total":  formatBytes(0),
+		"ipv4":      "",
+		"ipv6":      "",
+	}
+	if iface == "" {
+		// no default route, i.e. offline
+		s.prevIface = ""
+		return values, nil
+	}
+
+	counters, err := readNetDev()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := counters[iface]
+	if !ok {
+		return nil, fmt.Errorf("no such interface: %s", iface)
+	}
+
+	now := time.Now()
+	if s.prevIface == iface && c.rx >= s.prevRx && c.tx >= s.prevTx {
+		elapsed := now.Sub(s.prevTime).Seconds()
+		if elapsed > 0 {
+			values["rx-rate"] = formatRate(float64(c.rx-s.prevRx) / elapsed)
+			values["tx-rate"] = formatRate(float64(c.tx-s.prevTx) / elapsed)
+		}
+	}
+	s.prevIface, s.prevRx, s.prevTx, s.prevTime = iface, c.rx, c.tx, now
+
+	values["rx-total"] = formatBytes(c.rx)
+	values["tx-total"] = formatBytes(c.tx)
+
+	if state, err := os.ReadFile(filepath.Join(sysRoot, "class/net", iface, "operstate")); err == nil {
+		values["state"] = strings.TrimSpace(string(state))
+	}
+
+	values["ipv4"], values["ipv6"] = interfaceAddresses(iface)
+
+	return values, nil
+}
+
+type netCounters struct {
+	rx uint64
+	tx uint64
+}
+
+// readNetDev returns the received and transmitted bytes of each interface
+// from /proc/net/dev.
+func readNetDev() (map[string]netCounters, error) {
+	f, err := os.Open(filepath.Join(procRoot, "net/dev"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	counters := make(map[string]netCounters)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		name, data, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(data)
+		if len(fields) < 9 {
+			// header line
+			continue
+		}
+		rx, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		tx, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			continue
+		}
+		counters[strings.TrimSpace(name)] = netCounters{rx: rx, tx: tx}
+	}
+	return counters, s.Err()
+}
+
+// defaultRouteInterface returns the interface of the IPv4 default route with
+// the lowest metric from /proc/net/route, or "" if there is none.
+func defaultRouteInterface() (string, error) {
+	f, err := os.Open(filepath.Join(procRoot, "net/route"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// RTF_UP from linux/route.h
+	const routeUp = 0x1
+
+	best, bestMetric := "", uint64(math.MaxUint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(s.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 64)
+		if err != nil || flags&routeUp == 0 {
+			continue
+		}
+		metric, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+		if metric < bestMetric {
+			best, bestMetric = fields[0], metric
+		}
+	}
+	return best, s.Err()
+}
+
+// interfaceAddresses returns the first IPv4 and IPv6 addresses of an
+// interface, preferring global IPv6 addresses over link-local ones.
+func interfaceAddresses(name string) (ipv4, ipv6 string) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", ""
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", ""
+	}
+
+	linkLocal := ""
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		switch {
+		case ip.To4() != nil:
+			if ipv4 == "" {
+				ipv4 = ip.String()
+			}
+		case ip.IsLinkLocalUnicast():
+			if linkLocal == "" {
+				linkLocal = ip.String()
+			}
+		default:
+			if ipv6 == "" {
+				ipv6 = ip.String()
+			}
+		}
+	}
+	if ipv6 == "" {
+		ipv6 = linkLocal
+	}
+	return ipv4, ipv6
+}
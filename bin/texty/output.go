@@ -0,0 +1,24 @@
+package main
+
+// #cgo pkg-config: gdk-3.0
+// #define GDK_DISABLE_DEPRECATION_WARNINGS
+// #include <gdk/gdk.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/gotk3/gotk3/gdk"
+)
+
+// monitorPlugName returns the connector name of the monitor at index i of
+// screen, e.g. DP-1. GTK 3 has no other way of getting it, and gotk3 only
+// wraps gdk_screen_get_monitor_plug_name with the gtk_deprecated build tag.
+func monitorPlugName(screen *gdk.Screen, i int) string {
+	name := C.gdk_screen_get_monitor_plug_name((*C.GdkScreen)(unsafe.Pointer(screen.Native())), C.gint(i))
+	if name == nil {
+		return ""
+	}
+	defer C.g_free(C.gpointer(unsafe.Pointer(name)))
+	return C.GoString((*C.char)(unsafe.Pointer(name)))
+}
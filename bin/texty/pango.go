@@ -0,0 +1,25 @@
+package main
+
+// #cgo pkg-config: pango
+// #include <stdlib.h>
+// #include <pango/pango.h>
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// validateMarkup parses markup with Pango's parser, the one used by labels,
+// returning the parse error if it isn't valid.
+func validateMarkup(markup string) error {
+	cMarkup := C.CString(markup)
+	defer C.free(unsafe.Pointer(cMarkup))
+
+	var gerr *C.GError
+	if C.pango_parse_markup(cMarkup, -1, 0, nil, nil, nil, &gerr) == C.FALSE {
+		defer C.g_error_free(gerr)
+		return errors.New(C.GoString((*C.char)(unsafe.Pointer(gerr.message))))
+	}
+	return nil
+}
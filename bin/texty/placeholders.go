@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+// expandPlaceholders replaces `{name}` placeholders in format with the
+// corresponding values. Unknown placeholders are left untouched.
+func expandPlaceholders(format string, values map[string]string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(format, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(format[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(format[:start])
+		if value, ok := values[format[start+1:end]]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(format[start : end+1])
+		}
+		format = format[end+1:]
+	}
+	b.WriteString(format)
+	return b.String()
+}
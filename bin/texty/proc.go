@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+// cpuSource reports CPU usage from /proc/stat, computed from the difference
+// between consecutive updates. The first update reports the average usage
+// since boot.
+type cpuSource struct {
+	prev map[string]cpuTimes
+}
+
+func (s *cpuSource) values() (map[string]string, error) {
+	times, err := readCpuTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(times)+1)
+	cores := 0
+	for name, t := range times {
+		prev := s.prev[name]
+		total := t.total - prev.total
+		idle := t.idle - prev.idle
+		if t.total < prev.total || t.idle < prev.idle {
+			// counters were reset, e.g. after a CPU was hotplugged
+			total, idle = t.total, t.idle
+		}
+
+		key := name
+		if name == "cpu" {
+			key = "total"
+		} else {
+			cores++
+		}
+		values[key] = formatPercent(percent(total-idle, total))
+	}
+	values["cores"] = strconv.Itoa(cores)
+
+	s.prev = times
+	return values, nil
+}
+
+func readCpuTimes() (map[string]cpuTimes, error) {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	times := make(map[string]cpuTimes)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		// user nice system idle iowait irq softirq steal; guest time is
+		// already included in user and nice
+		var t cpuTimes
+		for i, field := range fields[1:min(len(fields), 9)] {
+			n, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s time: %s", fields[0], field)
+			}
+			t.total += n
+			if i == 3 || i == 4 {
+				t.idle += n
+			}
+		}
+		times[fields[0]] = t
+	}
+	return times, s.Err()
+}
+
+// memorySource reports memory and swap usage from /proc/meminfo.
+type memorySource struct{}
+
+func (memorySource) values() (map[string]string, error) {
+	info, err := readMeminfo()
+	if err != nil {
+		return nil, err
+	}
+
+	total := info["MemTotal"]
+	available, ok := info["MemAvailable"]
+	if !ok {
+		// kernels before 3.14 don't report MemAvailable
+		available = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	used := total - min(available, total)
+	swapTotal := info["SwapTotal"]
+	swapUsed := swapTotal - min(info["SwapFree"], swapTotal)
+
+	return map[string]string{
+		"total":        formatBytes(total),
+		"used":         formatBytes(used),
+		"free":         formatBytes(info["MemFree"]),
+		"available":    formatBytes(available),
+		"percent":      formatPercent(percent(used, total)),
+		"swap-total":   formatBytes(swapTotal),
+		"swap-used":    formatBytes(swapUsed),
+		"swap-free":    formatBytes(info["SwapFree"]),
+		"swap-percent": formatPercent(percent(swapUsed, swapTotal)),
+	}, nil
+}
+
+// readMeminfo returns the fields of /proc/meminfo in bytes.
+func readMeminfo() (map[string]uint64, error) {
+	f, err := os.Open(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", key, fields[0])
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		info[key] = n
+	}
+	return info, s.Err()
+}
+
+// loadSource reports the load averages from /proc/loadavg.
+type loadSource struct{}
+
+func (loadSource) values() (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "loadavg"))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid loadavg: %q", data)
+	}
+	running, processes, _ := strings.Cut(fields[3], "/")
+
+	return map[string]string{
+		"load1":     fields[0],
+		"load5":     fields[1],
+		"load15":    fields[2],
+		"running":   running,
+		"processes": processes,
+	}, nil
+}
+
+// uptimeSource reports the system uptime from /proc/uptime.
+type uptimeSource struct{}
+
+func (uptimeSource) values() (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "uptime"))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid uptime: %q", data)
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uptime: %s", fields[0])
+	}
+	uptime := time.Duration(seconds) * time.Second
+
+	return map[string]string{
+		"uptime":  formatDuration(uptime),
+		"days":    strconv.Itoa(int(uptime / (24 * time.Hour))),
+		"hours":   strconv.Itoa(int(uptime / time.Hour % 24)),
+		"minutes": strconv.Itoa(int(uptime / time.Minute % 60)),
+		"seconds": strconv.Itoa(int(uptime / time.Second % 60)),
+	}, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func withProcRoot(t *testing.T, root string) {
+	prev := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = prev })
+}
+
+func checkValues(t *testing.T, values map[string]string, want map[string]string) {
+	t.Helper()
+	for key, w := range want {
+		if got := values[key]; got != w {
+			t.Errorf("{%s} = %q, want %q", key, got, w)
+		}
+	}
+}
+
+func TestCpuSource(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	s := &cpuSource{}
+	values, err := s.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// since boot: 1500 busy out of 10000
+	checkValues(t, values, map[string]string{
+		"total": "15",
+		"cpu0":  "18",
+		"cpu1":  "12",
+		"cores": "2",
+	})
+
+	withProcRoot(t, "testdata/proc-next")
+	values, err = s.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// between ticks: 200 busy out of 500
+	checkValues(t, values, map[string]string{
+		"total": "40",
+		"cpu0":  "75",
+		"cpu1":  "17",
+	})
+}
+
+func TestMemorySource(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	values, err := memorySource{}.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]string{
+		"total":        "15.6 GiB",
+		"used":         "7.8 GiB",
+		"available":    "7.8 GiB",
+		"percent":      "50",
+		"swap-used":    "1000.0 MiB",
+		"swap-percent": "25",
+	})
+}
+
+func TestLoadSource(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	values, err := loadSource{}.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]string{
+		"load1":     "0.52",
+		"load5":     "0.58",
+		"load15":    "0.59",
+		"running":   "2",
+		"processes": "1234",
+	})
+}
+
+func TestUptimeSource(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	values, err := uptimeSource{}.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]string{
+		"uptime":  "3d 4h 4m",
+		"days":    "3",
+		"hours":   "4",
+		"minutes": "4",
+		"seconds": "5",
+	})
+}
+
+func TestNetworkSource(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	// eth0's default route isn't up, so wlan0 is used
+	iface, err := defaultRouteInterface()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iface != "wlan0" {
+		t.Errorf("default route interface = %q, want %q", iface, "wlan0")
+	}
+
+	s := &networkSource{iface: "auto"}
+	values, err := s.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]string{
+		"interface": "wlan0",
+		"rx-total":  "3.0 GiB",
+		"tx-total":  "50.0 MiB",
+		"rx-rate":   "0 B/s",
+	})
+
+	s = &networkSource{iface: "wlan1"}
+	if _, err := s.values(); err == nil {
+		t.Error("expected error for missing interface")
+	}
+}
+
+func TestReadMounts(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	mounts, err := readMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/", "/boot", "/home", "/mnt/My Files", "/mnt/usb"}
+	if !slices.Equal(mounts, want) {
+		t.Errorf("readMounts() = %q, want %q", mounts, want)
+	}
+}
+
+func TestDiskSource(t *testing.T) {
+	s := &diskSource{paths: []string{"/", "testdata"}}
+	rows, err := s.rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[1]["path"] != "testdata" {
+		t.Errorf("{path} = %q, want %q", rows[1]["path"], "testdata")
+	}
+
+	s = &diskSource{paths: []string{"testdata/missing", "/"}}
+	rows, err = s.rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["path"] != "/" {
+		t.Errorf("rows = %v, want only /", rows)
+	}
+	if s.failed["testdata/missing"] == "" {
+		t.Error("failing path not recorded")
+	}
+
+	s = &diskSource{paths: []string{"testdata/missing"}}
+	if _, err := s.rows(); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
+
+func TestProcessesSource(t *testing.T) {
+	withProcRoot(t, "testdata/proc")
+
+	s := &processesSource{sort: "cpu", count: 2, header: true, users: map[string]string{}}
+	rows, err := s.rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	// since boot: 400 and 150 ticks out of 10000 on 2 cores
+	checkValues(t, rows[0], map[string]string{"pid": "PID", "name": "NAME       ", "cpu": "CPU%"})
+	checkValues(t, rows[1], map[string]string{"pid": "100", "name": "Web Content", "cpu": " 8.0", "mem": "512.0 MiB"})
+	checkValues(t, rows[2], map[string]string{"pid": "200", "name": "Xwayland   ", "cpu": " 3.0", "mem": "  1.0 GiB"})
+
+	withProcRoot(t, "testdata/proc-next")
+	rows, err = s.rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// between ticks: 250 and 125 ticks out of 500
+	checkValues(t, rows[1], map[string]string{"pid": "100", "cpu": "100.0"})
+	checkValues(t, rows[2], map[string]string{"pid": "200", "cpu": " 50.0"})
+
+	s = &processesSource{sort: "mem", count: 5, users: map[string]string{}}
+	rows, err = s.rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pids []string
+	for _, row := range rows {
+		pids = append(pids, row["pid"])
+	}
+	if want := []string{"200", "100", "300"}; !slices.Equal(pids, want) {
+		t.Errorf("pids sorted by memory = %q, want %q", pids, want)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"texty"
+	"unicode/utf8"
+)
+
+// number of processes shown when count isn't set
+const defaultProcessCount = 5
+
+// titles of the columns in the header row of processes sources
+var processColumnTitles = map[string]string{
+	"pid":  "PID",
+	"name": "NAME",
+	"user": "USER",
+	"cpu":  "CPU%",
+	"mem":  "MEM",
+}
+
+// columns that are aligned to the right
+var numericProcessColumns = []string{"pid", "cpu", "mem"}
+
+type processInfo struct {
+	pid   int
+	name  string
+	uid   string
+	ticks uint64
+	rss   uint64
+	cpu   float64
+}
+
+// processesSource reports the top processes by CPU or memory usage from
+// /proc/[pid]/stat and /proc/[pid]/status, one line per process. Like top,
+// CPU usage is relative to a single core and computed from the difference
+// between consecutive updates, so the first update reports the average usage
+// since boot.
+type processesSource struct {
+	sort   string
+	count  int
+	header bool
+
+	prevTotal uint64
+	prevTicks map[int]uint64
+	users     map[string]string
+}
+
+func newProcessesSource(m *texty.Metric) *processesSource {
+	s := &processesSource{
+		sort:   m.Options["sort"],
+		count:  defaultProcessCount,
+		header: m.Options["header"] != "false",
+		users:  make(map[string]string),
+	}
+	if s.sort == "" {
+		s.sort = "cpu"
+	}
+	if count, err := strconv.Atoi(m.Options["count"]); err == nil {
+		s.count = count
+	}
+	return s
+}
+
+func (s *processesSource) rows() ([]map[string]string, error) {
+	times, err := readCpuTimes()
+	if err != nil {
+		return nil, err
+	}
+	total := times["cpu"].total
+	cores := len(times) - 1
+
+	processes, err := readProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := total - s.prevTotal
+	ticks := make(map[int]uint64, len(processes))
+	for i := range processes {
+		p := &processes[i]
+		ticks[p.pid] = p.ticks
+		// processes that weren't seen before started since the previous update
+		if prev := s.prevTicks[p.pid]; prev <= p.ticks && elapsed > 0 {
+			p.cpu = float64(p.ticks-prev) / float64(elapsed) * 100 * float64(cores)
+		}
+	}
+	s.prevTotal, s.prevTicks = total, ticks
+
+	slices.SortFunc(processes, func(a, b processInfo) int {
+		var c int
+		if s.sort == "mem" {
+			c = cmp.Compare(b.rss, a.rss)
+		} else {
+			c = cmp.Compare(b.cpu, a.cpu)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.pid, b.pid)
+		}
+		return c
+	})
+	processes = processes[:min(len(processes), s.count)]
+
+	var rows []map[string]string
+	if s.header {
+		rows = append(rows, processColumnTitles)
+	}
+	for _, p := range processes {
+		rows = append(rows, map[string]string{
+			"pid":  strconv.Itoa(p.pid),
+			"name": p.name,
+			"user": s.lookupUser(p.uid),
+			"cpu":  strconv.FormatFloat(p.cpu, 'f', 1, 64),
+			"mem":  formatBytes(p.rss),
+		})
+	}
+	return alignColumns(rows), nil
+}
+
+// lookupUser returns the name of the user with the given ID, or the ID itself
+// if it has no name.
+func (s *processesSource) lookupUser(uid string) string {
+	if name, ok := s.users[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	s.users[uid] = name
+	return name
+}
+
+// alignColumns pads the values of each column to the same width, so they
+// line up when displayed in a monospace font.
+func alignColumns(rows []map[string]string) []map[string]string {
+	widths := make(map[string]int)
+	for _, row := range rows {
+		for column, value := range row {
+			widths[column] = max(widths[column], utf8.RuneCountInString(value))
+		}
+	}
+
+	aligned := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		aligned[i] = make(map[string]string, len(row))
+		for column, value := range row {
+			padding := strings.Repeat(" ", widths[column]-utf8.RuneCountInString(value))
+			if slices.Contains(numericProcessColumns, column) {
+				aligned[i][column] = padding + value
+			} else {
+				aligned[i][column] = value + padding
+			}
+		}
+	}
+	return aligned
+}
+
+// readProcesses reads the name, owner, CPU time and resident memory of every
+// process. Processes that exit while they're being read are skipped.
+func readProcesses() ([]processInfo, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		p, err := readProcess(pid)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+func readProcess(pid int) (processInfo, error) {
+	p := processInfo{pid: pid}
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return p, err
+	}
+	// the name is in parentheses and may itself contain spaces and
+	// parentheses, so fields are counted from the last one
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return p, fmt.Errorf("invalid stat for process %d", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 13 {
+		return p, fmt.Errorf("invalid stat for process %d", pid)
+	}
+	// utime and stime, fields 14 and 15 of the whole line
+	for _, field := range fields[11:13] {
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid stat for process %d", pid)
+		}
+		p.ticks += n
+	}
+
+	f, err := os.Open(filepath.Join(dir, "status"))
+	if err != nil {
+		return p, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Name":
+			p.name = value
+		case "Uid":
+			// real, effective, saved and filesystem IDs
+			if fields := strings.Fields(value); len(fields) > 0 {
+				p.uid = fields[0]
+			}
+		case "VmRSS":
+			// kernel threads have no resident memory
+			kb, err := strconv.ParseUint(strings.TrimSuffix(value, " kB"), 10, 64)
+			if err == nil {
+				p.rss = kb * 1024
+			}
+		}
+	}
+	return p, s.Err()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// how often windows with stale-after are checked, in milliseconds
+const staleCheckInterval = 1000
+
+// checkStale marks the window as stale once it hasn't been updated
+// successfully for longer than its stale-after duration, appending its stale
+// suffix if it has one. The next successful update clears it. It must be
+// called on the main loop.
+func (w *window) checkStale() {
+	if w.closed || w.config.StaleAfter == nil {
+		return
+	}
+
+	age := time.Since(w.lastUpdate)
+	if age < time.Duration(*w.config.StaleAfter) {
+		return
+	}
+
+	w.setState(stateStale)
+
+	if w.config.StaleSuffix != nil {
+		suffix := expandPlaceholders(*w.config.StaleSuffix, map[string]string{
+			"age": glib.MarkupEscapeText(formatAge(age)),
+		})
+		if w.table != nil {
+			if suffix != w.tableSuffix {
+				w.tableSuffix = suffix
+				w.updateText(w.lastText)
+			}
+		} else if text := w.lastText + suffix; text != w.text {
+			w.updateText(text)
+		}
+	}
+}
+
+// formatAge formats a duration in its largest whole unit, e.g. "3 min".
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%d hr", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%d min", int(d/time.Minute))
+	default:
+		return fmt.Sprintf("%d sec", int(d/time.Second))
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"texty"
+	"time"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// sourceState is the state of a window's source. Each state is exposed as a
+// CSS class on the window.
+type sourceState string
+
+const (
+	stateLoading sourceState = "loading"
+	stateOk      sourceState = "ok"
+	stateError   sourceState = "error"
+	stateEmpty   sourceState = "empty"
+	stateStale   sourceState = "stale"
+)
+
+// text displayed for errors when error-text isn't set
+const defaultErrorText = "{error}"
+
+// sourceError describes a failed update, including the exit code and stderr
+// of the command that produced it, if any.
+type sourceError struct {
+	err      error
+	exitCode int
+	stderr   string
+}
+
+func (e *sourceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sourceError) Unwrap() error {
+	return e.err
+}
+
+// newSourceError wraps err, extracting the exit code and stderr from command
+// errors. stderr is used when the error itself doesn't carry any.
+func newSourceError(err error, stderr string) *sourceError {
+	e := &sourceError{err: err, exitCode: -1, stderr: stderr}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.exitCode = exitErr.ExitCode()
+		if e.stderr == "" {
+			e.stderr = string(exitErr.Stderr)
+		}
+	}
+	e.stderr = strings.TrimSpace(e.stderr)
+	return e
+}
+
+// setState replaces the window's state class. It must be called on the main
+// loop.
+func (w *window) setState(state sourceState) {
+	if w.state == state {
+		return
+	}
+
+	styleContext, err := w.window.GetStyleContext()
+	if err == nil {
+		if w.state != "" {
+			styleContext.RemoveClass(string(w.state))
+		}
+		styleContext.AddClass(string(state))
+	}
+	w.state = state
+}
+
+// setLoading puts the window in the loading state, displaying its loading
+// placeholder if it has one.
+func (w *window) setLoading() {
+	w.setState(stateLoading)
+	if w.config.Loading != nil {
+		w.updateText(*w.config.Loading)
+	}
+}
+
+// showText displays text produced by the window's source. It can be called
+// from any goroutine.
+func (w *window) showText(text string) {
+	glib.IdleAdd(func() {
+		w.setText(text)
+	})
+}
+
+// showSourceText displays text read from the window's external source, such
+// as a file or the output of a command. It can be called from any goroutine.
+func (w *window) showSourceText(text string) {
+	glib.IdleAdd(func() {
+		w.setText(w.sourceText(text, w.config.CommandAnsi))
+	})
+}
+
+// sourceText prepares text read from an external source for display: it runs
+// the window's transforms on the text as read, translates ANSI escape
+// sequences if ansi is set, and applies the window's markup mode. Built-in
+// sources generate their own markup, and placeholders are written in the
+// configuration, so neither goes through it. It must be called on the main
+// loop.
+func (w *window) sourceText(text string, ansi bool) string {
+	text = w.transformText(text, w.transformSyntax(ansi))
+	if ansi {
+		// the markup mode can't be changed with ansi
+		return ansiToMarkup(text)
+	}
+
+	// table cells are handled when the table is rendered, since escaping
+	// would break quoted fields
+	if w.config.Markup == nil || *w.config.Markup == texty.MarkupOn || w.table != nil {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = w.markupLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setText displays text produced by the window's source, or the window's
+// empty placeholder if there's nothing to display. It must be called on the
+// main loop.
+func (w *window) setText(text string) {
+	if w.closed {
+		return
+	}
+
+	w.restoreAfterError()
+
+	if strings.TrimSpace(text) == "" {
+		w.setState(stateEmpty)
+		if w.config.Empty != nil {
+			text = *w.config.Empty
+		}
+	} else {
+		w.setState(stateOk)
+	}
+
+	w.lastUpdate = time.Now()
+	w.lastText = text
+	w.tableSuffix = ""
+	w.updateText(text)
+}
+
+// showError displays a failed update according to the window's on-error
+// policy. It can be called from any goroutine.
+func (w *window) showError(err *sourceError) {
+	glib.IdleAdd(func() {
+		w.setError(err)
+	})
+}
+
+func (w *window) setError(err *sourceError) {
+	if w.closed {
+		return
+	}
+
+	w.setState(stateError)
+
+	policy := texty.ErrorKeep
+	if w.config.OnError != nil {
+		policy = *w.config.OnError
+	}
+
+	switch policy {
+	case texty.ErrorShow:
+		format := defaultErrorText
+		if w.config.ErrorText != nil {
+			format = *w.config.ErrorText
+		}
+		exitCode := ""
+		if err.exitCode >= 0 {
+			exitCode = strconv.Itoa(err.exitCode)
+		}
+		w.updateText(expandPlaceholders(format, map[string]string{
+			"error":     glib.MarkupEscapeText(err.Error()),
+			"exit-code": exitCode,
+			"stderr":    glib.MarkupEscapeText(err.stderr),
+		}))
+	case texty.ErrorHide:
+		if !w.errorHidden {
+			w.errorHidden = true
+			w.window.Hide()
+		}
+	}
+}
+
+// restoreAfterError shows the window again if it was hidden by an error.
+func (w *window) restoreAfterError() {
+	if w.errorHidden {
+		w.errorHidden = false
+		if !w.hidden {
+			w.window.ShowAll()
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeNames holds the day and month names of a locale, Sunday and January
+// first.
+type localeNames struct {
+	days        [7]string
+	shortDays   [7]string
+	months      [12]string
+	shortMonths [12]string
+}
+
+var englishNames = &localeNames{
+	days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	shortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+}
+
+// day and month names by language code; languages not listed here fall back
+// to English
+var localeTable = map[string]*localeNames{
+	"en": englishNames,
+	"de": {
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+	},
+	"es": {
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+	},
+	"fr": {
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+	},
+	"it": {
+		days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+	},
+	"nl": {
+		days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+	},
+	"pt": {
+		days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+	},
+	"sv": {
+		days:        [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		shortDays:   [7]string{"sön", "mån", "tis", "ons", "tor", "fre", "lör"},
+		months:      [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		shortMonths: [12]string{"jan", "feb", "mar", "apr", "maj", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+	},
+	"pl": {
+		days:        [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		shortDays:   [7]string{"nie", "pon", "wto", "śro", "czw", "pią", "sob"},
+		months:      [12]string{"styczeń", "luty", "marzec", "kwiecień", "maj", "czerwiec", "lipiec", "sierpień", "wrzesień", "październik", "listopad", "grudzień"},
+		shortMonths: [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+	},
+}
+
+// lookupLocale returns the names for a locale such as "de_DE.UTF-8". If locale
+// is empty, it is taken from the environment like the C library does.
+func lookupLocale(locale string) *localeNames {
+	if locale == "" {
+		for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+			if locale = os.Getenv(env); locale != "" {
+				break
+			}
+		}
+	}
+
+	lang, _, _ := strings.Cut(locale, "_")
+	lang, _, _ = strings.Cut(lang, ".")
+	if names, ok := localeTable[strings.ToLower(lang)]; ok {
+		return names
+	}
+	return englishNames
+}
+
+// strftime formats t according to a C strftime(3) format. The `-` flag (e.g.
+// `%-d`) removes padding from numeric conversions.
+func strftime(t time.Time, format string, names *localeNames) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+
+		pad := true
+		if format[i] == '-' && i < len(format)-1 {
+			pad = false
+			i++
+		}
+
+		num := func(n, width int, padding byte) {
+			s := strconv.Itoa(n)
+			if pad {
+				for len(s) < width {
+					s = string(padding) + s
+				}
+			}
+			b.WriteString(s)
+		}
+
+		switch format[i] {
+		case 'a':
+			b.WriteString(names.shortDays[t.Weekday()])
+		case 'A':
+			b.WriteString(names.days[t.Weekday()])
+		case 'b', 'h':
+			b.WriteString(names.shortMonths[t.Month()-1])
+		case 'B':
+			b.WriteString(names.months[t.Month()-1])
+		case 'c':
+			b.WriteString(strftime(t, "%a %b %e %H:%M:%S %Y", names))
+		case 'C':
+			num(t.Year()/100, 2, '0')
+		case 'd':
+			num(t.Day(), 2, '0')
+		case 'D':
+			b.WriteString(strftime(t, "%m/%d/%y", names))
+		case 'e':
+			num(t.Day(), 2, ' ')
+		case 'F':
+			b.WriteString(strftime(t, "%Y-%m-%d", names))
+		case 'G':
+			year, _ := t.ISOWeek()
+			num(year, 4, '0')
+		case 'g':
+			year, _ := t.ISOWeek()
+			num(year%100, 2, '0')
+		case 'H':
+			num(t.Hour(), 2, '0')
+		case 'I':
+			num(hour12(t), 2, '0')
+		case 'j':
+			num(t.YearDay(), 3, '0')
+		case 'k':
+			num(t.Hour(), 2, ' ')
+		case 'l':
+			num(hour12(t), 2, ' ')
+		case 'm':
+			num(int(t.Month()), 2, '0')
+		case 'M':
+			num(t.Minute(), 2, '0')
+		case 'n':
+			b.WriteByte('\n')
+		case 'p':
+			if t.Hour() < 12 {
+				b.WriteString("AM")
+			} else {
+				b.WriteString("PM")
+			}
+		case 'P':
+			if t.Hour() < 12 {
+				b.WriteString("am")
+			} else {
+				b.WriteString("pm")
+			}
+		case 'r':
+			b.WriteString(strftime(t, "%I:%M:%S %p", names))
+		case 'R':
+			b.WriteString(strftime(t, "%H:%M", names))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			num(t.Second(), 2, '0')
+		case 't':
+			b.WriteByte('\t')
+		case 'T', 'X':
+			b.WriteString(strftime(t, "%H:%M:%S", names))
+		case 'u':
+			weekday := int(t.Weekday())
+			if weekday == 0 {
+				weekday = 7
+			}
+			num(weekday, 1, '0')
+		case 'V':
+			_, week := t.ISOWeek()
+			num(week, 2, '0')
+		case 'w':
+			num(int(t.Weekday()), 1, '0')
+		case 'x':
+			b.WriteString(strftime(t, "%m/%d/%y", names))
+		case 'y':
+			num(t.Year()%100, 2, '0')
+		case 'Y':
+			num(t.Year(), 4, '0')
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case '%':
+			b.WriteByte('%')
+		default:
+			// unknown conversions are output verbatim
+			fmt.Fprintf(&b, "%%%c", format[i])
+		}
+	}
+	return b.String()
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		h = 12
+	}
+	return h
+}
+
+// strftimeResolution returns how often the output of format can change.
+func strftimeResolution(format string) time.Duration {
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if format[i] == '-' && i < len(format)-1 {
+			i++
+		}
+		switch format[i] {
+		case 'S', 's', 'T', 'X', 'c', 'r':
+			return time.Second
+		}
+	}
+	return time.Minute
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStrftime(t *testing.T) {
+	tm := time.Date(2026, time.March, 5, 14, 7, 9, 0, time.UTC)
+
+	tests := []struct {
+		format string
+		locale string
+		want   string
+	}{
+		{"%H:%M:%S", "en", "14:07:09"},
+		{"%a %d %b  %H:%M:%S", "en", "Thu 05 Mar  14:07:09"},
+		{"%A, %-d. %B %Y", "de_DE.UTF-8", "Donnerstag, 5. März 2026"},
+		{"%I:%M %p", "en", "02:07 PM"},
+		{"%l:%M%P", "en", " 2:07pm"},
+		{"%F %T %z", "en", "2026-03-05 14:07:09 +0000"},
+		{"%j %u %w %V", "en", "064 4 4 10"},
+		{"100%% %Q", "en", "100% %Q"},
+		{"%A", "xx_XX", "Thursday"},
+	}
+
+	for _, test := range tests {
+		got := strftime(tm, test.format, lookupLocale(test.locale))
+		if got != test.want {
+			t.Errorf("strftime(%q, %q) = %q, want %q", test.format, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestStrftimeResolution(t *testing.T) {
+	tests := map[string]time.Duration{
+		"%H:%M":    time.Minute,
+		"%H:%M:%S": time.Second,
+		"%T":       time.Second,
+		"%-S":      time.Second,
+		"%%S":      time.Minute,
+		"%a %d %b": time.Minute,
+	}
+
+	for format, want := range tests {
+		if got := strftimeResolution(format); got != want {
+			t.Errorf("strftimeResolution(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
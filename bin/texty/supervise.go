@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"texty"
+	"time"
+
+	"github.com/gotk3/gotk3/glib"
+)
+
+// defaults for the exponential backoff between restarts
+const (
+	defaultRestartDelay = time.Second
+	defaultRestartMax   = time.Minute
+)
+
+// returned by output readers when they stop because the window was closed
+var errWindowClosed = errors.New("window closed")
+
+// number of stderr lines kept for a window's error text
+const stderrTailLines = 10
+
+// supervise runs the window's long-running command, passing its stdout to
+// read, and restarts it according to the window's restart policy. read should
+// return nil once the output ends, or an error if it can't make sense of it.
+func (w *window) supervise(read func(out io.Reader) error) {
+	policy := texty.RestartNever
+	if w.config.Restart != nil {
+		policy = *w.config.Restart
+	}
+	initialDelay := defaultRestartDelay
+	if w.config.RestartDelay != nil {
+		initialDelay = time.Duration(*w.config.RestartDelay)
+	}
+	maxDelay := defaultRestartMax
+	if w.config.RestartMax != nil {
+		maxDelay = time.Duration(*w.config.RestartMax)
+	}
+
+	delay := initialDelay
+	for {
+		started := time.Now()
+		err := w.run(read)
+		if w.closed {
+			return
+		}
+
+		if err != nil {
+			log.Printf("warning: command for window %s failed: %v", w.config.Id, err)
+			w.showError(err)
+		} else {
+			log.Printf("command for window %s exited", w.config.Id)
+		}
+
+		if policy == texty.RestartNever || (policy == texty.RestartOnFailure && err == nil) {
+			w.setDown("command exited")
+			return
+		}
+
+		// a command that stayed up for a while is considered healthy again, so
+		// the backoff starts over
+		if time.Since(started) > maxDelay {
+			delay = initialDelay
+		}
+
+		restarts := w.restarts.Add(1)
+		log.Printf("restarting command for window %s in %v (restart #%d)", w.config.Id, delay, restarts)
+		w.setDown(fmt.Sprintf("command exited, restarting in %v", delay))
+
+		time.Sleep(delay)
+		if w.closed {
+			return
+		}
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+// run starts the window's command once and waits for it to exit. Its stderr is
+// copied to the log.
+func (w *window) run(read func(out io.Reader) error) *sourceError {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := w.command(ctx)
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return newSourceError(fmt.Errorf("failed to get stdout pipe: %w", err), "")
+	}
+	stderr, err := c.StderrPipe()
+	if err != nil {
+		return newSourceError(fmt.Errorf("failed to get stderr pipe: %w", err), "")
+	}
+	if err := c.Start(); err != nil {
+		return newSourceError(fmt.Errorf("failed to start command: %w", err), "")
+	}
+
+	// the last few lines of stderr are kept for the window's error text
+	var stderrTail []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s := bufio.NewScanner(stderr)
+		for s.Scan() {
+			log.Printf("%s: %s", w.config.Id, s.Text())
+			stderrTail = append(stderrTail, s.Text())
+			if len(stderrTail) > stderrTailLines {
+				stderrTail = stderrTail[1:]
+			}
+		}
+	}()
+
+	w.setDown("")
+
+	readErr := read(stdout)
+	if readErr != nil {
+		// the output can't be used anymore, so don't leave the command running
+		cancel()
+	}
+
+	// all reads from the pipes must finish before waiting for the command
+	wg.Wait()
+	waitErr := c.Wait()
+
+	if readErr != nil {
+		return newSourceError(readErr, strings.Join(stderrTail, "\n"))
+	}
+	if waitErr != nil {
+		return newSourceError(waitErr, strings.Join(stderrTail, "\n"))
+	}
+	return nil
+}
+
+// setDown shows status in the window while its command isn't running, or
+// clears it if status is empty.
+func (w *window) setDown(status string) {
+	glib.IdleAdd(func() {
+		if w.closed || w.status == status {
+			return
+		}
+		w.status = status
+
+		styleContext, err := w.window.GetStyleContext()
+		if err == nil {
+			if status != "" {
+				styleContext.AddClass("down")
+			} else {
+				styleContext.RemoveClass("down")
+			}
+		}
+
+		w.updateText(w.text)
+	})
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readSysString returns the trimmed contents of a sysfs attribute.
+func readSysString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSysInt returns the contents of a numeric sysfs attribute.
+func readSysInt(path string) (int64, error) {
+	s, err := readSysString(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %q", path, s)
+	}
+	return n, nil
+}
+
+// findSysDevice returns the directory of the first device in a sysfs class
+// directory, in name order, for which match returns true.
+func findSysDevice(class string, match func(dir string) bool) (string, bool) {
+	entries, err := os.ReadDir(filepath.Join(sysRoot, "class", class))
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		dir := filepath.Join(sysRoot, "class", class, entry.Name())
+		if match(dir) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// powerSupplySource reports the state of a battery or AC adapter from
+// /sys/class/power_supply. Without a name, the first power supply of the
+// given type is used.
+type powerSupplySource struct {
+	name       string
+	supplyType string
+}
+
+func (s *powerSupplySource) values() (map[string]string, error) {
+	var dir string
+	if s.name != "" {
+		dir = filepath.Join(sysRoot, "class/power_supply", s.name)
+	} else {
+		var ok bool
+		dir, ok = findSysDevice("power_supply", func(dir string) bool {
+			t, err := readSysString(filepath.Join(dir, "type"))
+			return err == nil && t == s.supplyType
+		})
+		if !ok {
+			return nil, fmt.Errorf("no %s power supply found", strings.ToLower(s.supplyType))
+		}
+	}
+
+	if s.supplyType == "Mains" {
+		online, err := readSysInt(filepath.Join(dir, "online"))
+		if err != nil {
+			return nil, err
+		}
+		state := "offline"
+		if online == 1 {
+			state = "online"
+		}
+		return map[string]string{
+			"name":  filepath.Base(dir),
+			"state": state,
+		}, nil
+	}
+
+	capacity, err := readSysInt(filepath.Join(dir, "capacity"))
+	if err != nil {
+		return nil, err
+	}
+	status, err := readSysString(filepath.Join(dir, "status"))
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := ""
+	if d, ok := batteryTimeRemaining(dir, status); ok {
+		remaining = formatDuration(d)
+	}
+
+	return map[string]string{
+		"name":     filepath.Base(dir),
+		"capacity": strconv.FormatInt(capacity, 10),
+		"status":   status,
+		"time":     remaining,
+	}, nil
+}
+
+// batteryTimeRemaining estimates the time until a battery is empty, or full
+// while charging, from its energy (µWh and µW) or charge (µAh and µA)
+// attributes, whichever the driver provides.
+func batteryTimeRemaining(dir, status string) (time.Duration, bool) {
+	for _, attrs := range [][3]string{
+		{"energy_now", "energy_full", "power_now"},
+		{"charge_now", "charge_full", "current_now"},
+	} {
+		now, err := readSysInt(filepath.Join(dir, attrs[0]))
+		if err != nil {
+			continue
+		}
+		full, err := readSysInt(filepath.Join(dir, attrs[1]))
+		if err != nil {
+			continue
+		}
+		rate, err := readSysInt(filepath.Join(dir, attrs[2]))
+		if err != nil {
+			continue
+		}
+		// some drivers report a negative rate while discharging
+		rate = max(rate, -rate)
+		if rate == 0 {
+			return 0, false
+		}
+
+		var hours float64
+		switch status {
+		case "Discharging":
+			hours = float64(now) / float64(rate)
+		case "Charging":
+			hours = float64(full-now) / float64(rate)
+		default:
+			return 0, false
+		}
+		return time.Duration(hours * float64(time.Hour)), true
+	}
+	return 0, false
+}
+
+// temperatureSource reports a temperature in degrees Celsius, either from a
+// thermal zone in /sys/class/thermal, selected by its directory name or type
+// (thermal_zone0 by default), or from a hwmon sensor in /sys/class/hwmon,
+// selected by its name and optionally the label of one of its inputs.
+type temperatureSource struct {
+	zone  string
+	hwmon string
+	label string
+}
+
+func (s *temperatureSource) values() (map[string]string, error) {
+	var path, sensor string
+	if s.hwmon != "" {
+		var err error
+		path, sensor, err = s.hwmonInput()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		zone := s.zone
+		if zone == "" {
+			zone = "thermal_zone0"
+		}
+		dir, ok := findSysDevice("thermal", func(dir string) bool {
+			if filepath.Base(dir) == zone {
+				return true
+			}
+			t, err := readSysString(filepath.Join(dir, "type"))
+			return err == nil && t == zone
+		})
+		if !ok {
+			return nil, fmt.Errorf("no thermal zone found: %s", zone)
+		}
+		path = filepath.Join(dir, "temp")
+		sensor, _ = readSysString(filepath.Join(dir, "type"))
+	}
+
+	millidegrees, err := readSysInt(path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"temp":   strconv.Itoa(int(math.Round(float64(millidegrees) / 1000))),
+		"sensor": sensor,
+	}, nil
+}
+
+// hwmonInput returns the path of the input of the source's hwmon sensor and
+// its label.
+func (s *temperatureSource) hwmonInput() (string, string, error) {
+	dir, ok := findSysDevice("hwmon", func(dir string) bool {
+		name, err := readSysString(filepath.Join(dir, "name"))
+		return err == nil && name == s.hwmon
+	})
+	if !ok {
+		return "", "", fmt.Errorf("no hwmon sensor found: %s", s.hwmon)
+	}
+
+	if s.label == "" {
+		return filepath.Join(dir, "temp1_input"), s.hwmon, nil
+	}
+
+	labels, err := filepath.Glob(filepath.Join(dir, "temp*_label"))
+	if err != nil {
+		return "", "", err
+	}
+	for _, path := range labels {
+		if label, err := readSysString(path); err == nil && label == s.label {
+			return strings.TrimSuffix(path, "_label") + "_input", label, nil
+		}
+	}
+	return "", "", fmt.Errorf("no input labeled %q found for hwmon sensor %s", s.label, s.hwmon)
+}
+
+// backlightSource reports the brightness of a backlight from
+// /sys/class/backlight. Without a device, the first backlight is used.
+type backlightSource struct {
+	device string
+}
+
+func (s *backlightSource) values() (map[string]string, error) {
+	var dir string
+	if s.device != "" {
+		dir = filepath.Join(sysRoot, "class/backlight", s.device)
+	} else {
+		var ok bool
+		dir, ok = findSysDevice("backlight", func(string) bool { return true })
+		if !ok {
+			return nil, fmt.Errorf("no backlight found")
+		}
+	}
+
+	// actual_brightness is what the hardware reports, which may differ from
+	// the requested brightness
+	brightness, err := readSysInt(filepath.Join(dir, "actual_brightness"))
+	if err != nil {
+		brightness, err = readSysInt(filepath.Join(dir, "brightness"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	maxBrightness, err := readSysInt(filepath.Join(dir, "max_brightness"))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"device":     filepath.Base(dir),
+		"percent":    formatPercent(percent(uint64(brightness), uint64(maxBrightness))),
+		"brightness": strconv.FormatInt(brightness, 10),
+		"max":        strconv.FormatInt(maxBrightness, 10),
+	}, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+)
+
+func withSysRoot(t *testing.T, root string) {
+	prev := sysRoot
+	sysRoot = root
+	t.Cleanup(func() { sysRoot = prev })
+}
+
+func TestBatterySource(t *testing.T) {
+	withSysRoot(t, "testdata/sys")
+
+	values, err := (&powerSupplySource{supplyType: "Battery"}).values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 28.5 Wh left at 9.5 W
+	checkValues(t, values, map[string]string{
+		"name":     "BAT0",
+		"capacity": "57",
+		"status":   "Discharging",
+		"time":     "3h 0m",
+	})
+}
+
+func TestAcSource(t *testing.T) {
+	withSysRoot(t, "testdata/sys")
+
+	values, err := (&powerSupplySource{supplyType: "Mains"}).values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]string{
+		"name":  "AC",
+		"state": "offline",
+	})
+}
+
+func TestTemperatureSource(t *testing.T) {
+	withSysRoot(t, "testdata/sys")
+
+	tests := []struct {
+		source temperatureSource
+		temp   string
+		sensor string
+	}{
+		{temperatureSource{}, "41", "acpitz"},
+		{temperatureSource{zone: "x86_pkg_temp"}, "53", "x86_pkg_temp"},
+		{temperatureSource{hwmon: "nvme"}, "39", "nvme"},
+		{temperatureSource{hwmon: "coretemp", label: "Core 0"}, "49", "Core 0"},
+	}
+	for _, tt := range tests {
+		values, err := tt.source.values()
+		if err != nil {
+			t.Errorf("%+v: %v", tt.source, err)
+			continue
+		}
+		checkValues(t, values, map[string]string{
+			"temp":   tt.temp,
+			"sensor": tt.sensor,
+		})
+	}
+
+	if _, err := (&temperatureSource{hwmon: "coretemp", label: "Core 9"}).values(); err == nil {
+		t.Error("expected error for missing label")
+	}
+}
+
+func TestBacklightSource(t *testing.T) {
+	withSysRoot(t, "testdata/sys")
+
+	values, err := (&backlightSource{}).values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]string{
+		"device":  "intel_backlight",
+		"percent": "50",
+		"max":     "19200",
+	})
+}
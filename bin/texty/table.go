@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"texty"
+	"unicode/utf8"
+
+	"github.com/gotk3/gotk3/gtk"
+)
+
+var tableWhitespace = regexp.MustCompile(`\s+`)
+
+// tableSplitter splits the text of a format=table window into rows of cells.
+type tableSplitter struct {
+	config *texty.Table
+	// set for single character delimiters, which follow CSV quoting rules
+	comma rune
+	// set for whitespace and regular expression delimiters
+	delimiter *regexp.Regexp
+
+	// last parse warning, so it's only logged once
+	warning string
+}
+
+func newTableSplitter(config *texty.Table) (*tableSplitter, error) {
+	s := &tableSplitter{config: config}
+	switch {
+	case config.Delimiter == "whitespace":
+		s.delimiter = tableWhitespace
+	case utf8.RuneCountInString(config.Delimiter) == 1:
+		s.comma, _ = utf8.DecodeRuneInString(config.Delimiter)
+	default:
+		re, err := regexp.Compile(config.Delimiter)
+		if err != nil {
+			return nil, err
+		}
+		s.delimiter = re
+	}
+	return s, nil
+}
+
+// split splits text into rows of cells, skipping blank lines.
+func (s *tableSplitter) split(text string) [][]string {
+	if s.delimiter == nil {
+		return s.splitQuoted(text)
+	}
+
+	limit := -1
+	if s.config.Columns > 0 {
+		limit = s.config.Columns
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if s.delimiter == tableWhitespace {
+			line = strings.TrimSpace(line)
+		}
+		rows = append(rows, s.delimiter.Split(line, limit))
+	}
+	return rows
+}
+
+// splitQuoted splits text using CSV quoting rules, with the configured
+// delimiter instead of commas. Each line is a row, and lines that can't be
+// parsed are split on the delimiter, ignoring quotes.
+func (s *tableSplitter) splitQuoted(text string) [][]string {
+	limit := -1
+	if s.config.Columns > 0 {
+		limit = s.config.Columns
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		r := csv.NewReader(strings.NewReader(line))
+		r.Comma = s.comma
+		r.FieldsPerRecord = -1
+		record, err := r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				err = parseErr.Err
+			}
+			if warning := fmt.Sprintf("%q: %v", line, err); warning != s.warning {
+				log.Printf("warning: failed to parse table line %s", warning)
+				s.warning = warning
+			}
+			rows = append(rows, strings.SplitN(line, string(s.comma), limit))
+			continue
+		}
+
+		if limit > 0 && len(record) > limit {
+			record = append(record[:limit-1], strings.Join(record[limit-1:], string(s.comma)))
+		}
+		rows = append(rows, record)
+	}
+	return rows
+}
+
+// addTable adds a grid displaying rows to the window's content. It must be
+// called on the main loop.
+func (w *window) addTable(rows [][]string, spacing int) {
+	grid, err := gtk.GridNew()
+	if err != nil {
+		log.Printf("warning: failed to create table: %v", err)
+		return
+	}
+	grid.SetColumnSpacing(uint(w.table.config.ColumnSpacing))
+	grid.SetRowSpacing(uint(spacing))
+	grid.SetMarginStart(8)
+	grid.SetMarginEnd(8)
+	grid.SetMarginBottom(spacing)
+	grid.SetHAlign(w.contentAlign())
+	if styleContext, err := grid.GetStyleContext(); err == nil {
+		styleContext.AddClass("table")
+	}
+
+	for i, row := range rows {
+		header := i == 0 && w.table.config.Header
+		for j, cell := range row {
+			label, err := gtk.LabelNew("")
+			if err != nil {
+				log.Printf("warning: failed to create label: %v", err)
+				continue
+			}
+
+			markup := w.markupLine(cell)
+			if header {
+				markup = "<b>" + markup + "</b>"
+				if styleContext, err := label.GetStyleContext(); err == nil {
+					styleContext.AddClass("header")
+				}
+			}
+			label.SetMarkup(markup)
+
+			align := gtk.ALIGN_START
+			if j < len(w.table.config.Align) {
+				align = w.table.config.Align[j]
+			}
+			label.SetHAlign(align)
+			w.limitWidth(label, align)
+			grid.Attach(label, j, i, 1, 1)
+		}
+	}
+
+	w.contentBox.PackStart(grid, true, false, 0)
+}
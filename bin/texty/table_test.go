@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"texty"
+)
+
+func TestTableSplit(t *testing.T) {
+	tests := []struct {
+		table texty.Table
+		text  string
+		want  [][]string
+	}{
+		{
+			texty.Table{Delimiter: "whitespace"},
+			"Filesystem  Size  Use%\n\n  /dev/sda1   50G   42%  \n",
+			[][]string{{"Filesystem", "Size", "Use%"}, {"/dev/sda1", "50G", "42%"}},
+		},
+		{
+			texty.Table{Delimiter: "whitespace", Columns: 3},
+			"PID USER COMMAND\n1 root /sbin/init splash",
+			[][]string{{"PID", "USER", "COMMAND"}, {"1", "root", "/sbin/init splash"}},
+		},
+		{
+			texty.Table{Delimiter: ","},
+			"name,note\r\nweb,\"up, 3 days\"\r\ndb,\"says \"\"hi\"\"\"\r\n",
+			[][]string{{"name", "note"}, {"web", "up, 3 days"}, {"db", `says "hi"`}},
+		},
+		{
+			texty.Table{Delimiter: "\t", Columns: 2},
+			"a\tb\tc\nd",
+			[][]string{{"a", "b\tc"}, {"d"}},
+		},
+		{
+			texty.Table{Delimiter: ","},
+			"a,\"b\nc,d\ne,\"f\"g\"\nh,i",
+			[][]string{{"a", `"b`}, {"c", "d"}, {"e", `"f"g"`}, {"h", "i"}},
+		},
+		{
+			texty.Table{Delimiter: ` *\| *`},
+			"a | b|c\r\n",
+			[][]string{{"a", "b", "c"}},
+		},
+	}
+	for _, tt := range tests {
+		s, err := newTableSplitter(&tt.table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.split(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTableSplitWarning(t *testing.T) {
+	s, err := newTableSplitter(&texty.Table{Delimiter: ","})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.split("a,b\nc,\"d")
+	warning := s.warning
+	if warning == "" {
+		t.Fatal("no warning for invalid line")
+	}
+	s.split("a,b\nc,\"d\ne,f")
+	if s.warning != warning {
+		t.Errorf("warning = %q, want %q", s.warning, warning)
+	}
+	s.split("\"x\"y")
+	if s.warning == warning {
+		t.Errorf("warning not updated for a different invalid line")
+	}
+}
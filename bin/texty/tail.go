@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// number of lines shown when following a file without tail set
+const defaultFollowLines = 10
+
+// size of the chunks read backwards from the end of a file
+const tailChunkSize = 64 * 1024
+
+// readLastLines returns the last n lines of the first size bytes of r that
+// match filter, reading backwards from the end so only as much of the file
+// as necessary is read. If n is zero or negative, all matching lines are
+// returned.
+func readLastLines(r io.ReaderAt, size int64, n int, filter *regexp.Regexp) ([]string, error) {
+	// matching lines, last first
+	var lines []string
+	// the start of the earliest line read so far, which may continue in the
+	// previous chunk, in reverse order
+	var fragments [][]byte
+
+	add := func(start []byte) {
+		line := make([]byte, 0, len(start))
+		line = append(line, start...)
+		for i := len(fragments) - 1; i >= 0; i-- {
+			line = append(line, fragments[i]...)
+		}
+		fragments = nil
+		if filter == nil || filter.Match(line) {
+			lines = append(lines, string(line))
+		}
+	}
+
+	pos := size
+	for pos > 0 && (n <= 0 || len(lines) < n) {
+		chunk := min(tailChunkSize, pos)
+		pos -= chunk
+		b := make([]byte, chunk)
+		if _, err := r.ReadAt(b, pos); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		end := len(b)
+		for n <= 0 || len(lines) < n {
+			i := bytes.LastIndexByte(b[:end], '\n')
+			if i < 0 {
+				break
+			}
+			// a newline at the end of the file doesn't start another line
+			if pos+int64(i) != size-1 {
+				add(b[i+1 : end])
+			}
+			end = i
+		}
+		fragments = append(fragments, b[:end])
+
+		// the start of the file also starts a line
+		if pos == 0 && (n <= 0 || len(lines) < n) {
+			add(nil)
+		}
+	}
+
+	slices.Reverse(lines)
+	return lines, nil
+}
+
+// splitLines splits buf into lines.
+func splitLines(buf []byte) []string {
+	if len(buf) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+}
+
+func filterLines(lines []string, filter *regexp.Regexp) []string {
+	if filter == nil {
+		return lines
+	}
+	filtered := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if filter.MatchString(line) {
+			filtered = append(filtered, line)
+		}
+	}
+	return filtered
+}
+
+// readFileTail reads the lines of a file selected by the window's tail and
+// filter options.
+func (w *window) readFileTail() (string, error) {
+	f, err := os.Open(*w.config.File)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	n := 0
+	if w.config.FileTail != nil {
+		n = *w.config.FileTail
+	}
+	lines, err := readLastLines(f, info.Size(), n, w.fileFilter)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// tailFollower follows a file like `tail -F`, keeping its last lines as data
+// is appended and handling truncation and rotation.
+type tailFollower struct {
+	path   string
+	n      int
+	filter *regexp.Regexp
+	file   *os.File
+	// offset of the first byte that hasn't been read yet; incomplete lines at
+	// the end of the file aren't consumed until they're terminated
+	offset int64
+	lines  []string
+}
+
+// poll reads any new data from the file and reports whether its lines
+// changed.
+func (t *tailFollower) poll() (bool, error) {
+	if t.file == nil {
+		f, err := os.Open(t.path)
+		if os.IsNotExist(err) {
+			// wait for the file to be created
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, t.open(f)
+	}
+
+	// if the path now refers to another file, it was rotated: like tail -F,
+	// what's left of the old file is read before the new file is read from
+	// the start
+	changed := false
+	if info, err := os.Stat(t.path); err == nil {
+		if current, err := t.file.Stat(); err == nil && !os.SameFile(info, current) {
+			f, err := os.Open(t.path)
+			if err != nil {
+				return false, err
+			}
+			changed, err = t.readNew(true)
+			if err != nil {
+				log.Printf("warning: failed to read rotated file: %v", err)
+			}
+			t.file.Close()
+			t.file = f
+			t.offset = 0
+		}
+	}
+
+	added, err := t.readNew(false)
+	return changed || added, err
+}
+
+// readNew reads the lines added to the file since the last read, reporting
+// whether any were kept. An unterminated line at the end of the file is left
+// for the next read unless final is set.
+func (t *tailFollower) readNew(final bool) (bool, error) {
+	info, err := t.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	if info.Size() < t.offset {
+		// the file was truncated; start over
+		t.offset = 0
+		t.lines = nil
+		changed = true
+	}
+	if info.Size() == t.offset {
+		return changed, nil
+	}
+
+	buf := make([]byte, info.Size()-t.offset)
+	if _, err := t.file.ReadAt(buf, t.offset); err != nil && err != io.EOF {
+		return changed, err
+	}
+
+	end := len(buf)
+	if !final {
+		end = bytes.LastIndexByte(buf, '\n') + 1
+		if end == 0 {
+			return changed, nil
+		}
+	}
+	t.offset += int64(end)
+
+	added := filterLines(splitLines(buf[:end]), t.filter)
+	if len(added) == 0 {
+		return changed, nil
+	}
+	t.lines = append(t.lines, added...)
+	if len(t.lines) > t.n {
+		t.lines = t.lines[len(t.lines)-t.n:]
+	}
+	return true, nil
+}
+
+// open starts following f, reading its last lines.
+func (t *tailFollower) open(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	// only complete lines are read
+	end := info.Size()
+	if end > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, end-1); err != nil {
+			f.Close()
+			return err
+		}
+		if last[0] != '\n' {
+			lines, err := readLastLines(f, end, 1, nil)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			if len(lines) > 0 {
+				end -= int64(len(lines[0]))
+			}
+		}
+	}
+
+	lines, err := readLastLines(f, end, t.n, t.filter)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	t.file = f
+	t.offset = end
+	t.lines = lines
+	return nil
+}
+
+func (t *tailFollower) text() string {
+	return strings.Join(t.lines, "\n")
+}
+
+func (t *tailFollower) Close() error {
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
+
+// followFile displays the end of the window's file, updating it whenever the
+// file changes.
+func (w *window) followFile() {
+	path, err := filepath.Abs(*w.config.File)
+	if err != nil {
+		log.Printf("warning: failed to resolve file path: %v", err)
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("warning: failed to create watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	// the directory is watched so that rotated and recreated files are noticed
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		log.Printf("warning: failed to add watcher: %v", err)
+		return
+	}
+
+	n := defaultFollowLines
+	if w.config.FileTail != nil {
+		n = *w.config.FileTail
+	}
+	t := &tailFollower{path: path, n: n, filter: w.fileFilter}
+	defer t.Close()
+
+	update := func() {
+		changed, err := t.poll()
+		if err != nil {
+			log.Printf("warning: failed to read file: %v", err)
+			w.showError(newSourceError(err, ""))
+			return
+		}
+		if changed {
+			w.showSourceText(t.text())
+		}
+	}
+
+	update()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Name != path {
+				continue
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watcher error: %v", err)
+			continue
+		}
+
+		if w.closed {
+			return
+		}
+		update()
+	}
+}
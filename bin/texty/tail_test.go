@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadLastLines(t *testing.T) {
+	// lines longer than a chunk, and many lines crossing chunk boundaries
+	long := strings.Repeat("x", tailChunkSize+100)
+	var numbered []string
+	for i := range 500 {
+		numbered = append(numbered, fmt.Sprintf("%03d %s", i, strings.Repeat("-", 1000)))
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		n      int
+		filter string
+		want   []string
+	}{
+		{"empty", "", 3, "", nil},
+		{"terminated", "a\nb\nc\n", 2, "", []string{"b", "c"}},
+		{"unterminated", "a\nb\nc", 2, "", []string{"b", "c"}},
+		{"all", "a\n\nb\n", 0, "", []string{"a", "", "b"}},
+		{"blank first line", "\na", 0, "", []string{"", "a"}},
+		{"fewer lines", "a\nb", 5, "", []string{"a", "b"}},
+		{"long lines", long + "\na\n" + long, 0, "", []string{long, "a", long}},
+		{"long last line", "a\n" + long, 1, "", []string{long}},
+		{"filter", "error 1\ninfo\nerror 2\ninfo\nerror 3\n", 2, "^error", []string{"error 2", "error 3"}},
+		{"filter chunks", strings.Join(numbered, "\n"), 3, `^\d\d[05] `, []string{numbered[485], numbered[490], numbered[495]}},
+		{"tail chunks", strings.Join(numbered, "\n") + "\n", 0, "", numbered},
+	}
+	for _, tt := range tests {
+		var filter *regexp.Regexp
+		if tt.filter != "" {
+			filter = regexp.MustCompile(tt.filter)
+		}
+		got, err := readLastLines(strings.NewReader(tt.text), int64(len(tt.text)), tt.n, filter)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %d lines %.80q, want %d lines %.80q", tt.name, len(got), got, len(tt.want), tt.want)
+		}
+	}
+}
+
+// appendFile appends text to the file at path.
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkPoll polls t and checks whether it changed and its lines.
+func checkPoll(t *testing.T, f *tailFollower, changed bool, want ...string) {
+	t.Helper()
+	got, err := f.poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != changed {
+		t.Errorf("changed = %v, want %v", got, changed)
+	}
+	if !slices.Equal(f.lines, want) {
+		t.Errorf("lines = %q, want %q", f.lines, want)
+	}
+}
+
+func TestTailFollower(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	f := &tailFollower{path: path, n: 3}
+	defer f.Close()
+
+	// the file doesn't exist yet
+	checkPoll(t, f, false)
+
+	// unterminated lines are only read once they're complete
+	appendFile(t, path, "a\nb\npart")
+	checkPoll(t, f, true, "a", "b")
+	checkPoll(t, f, false, "a", "b")
+	appendFile(t, path, "ial\nc\n")
+	checkPoll(t, f, true, "b", "partial", "c")
+
+	// truncation starts over
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "new\n")
+	checkPoll(t, f, true, "new")
+
+	// rotation reads what's left of the old file, including an unterminated
+	// line, then the new file
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "old 1\nold 2")
+	appendFile(t, path, "rotated\n")
+	checkPoll(t, f, true, "old 1", "old 2", "rotated")
+}
+
+func TestTailFollowerFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	appendFile(t, path, "keep 1\ndrop\nkeep 2\nkeep 3\ndrop\n")
+
+	f := &tailFollower{path: path, n: 2, filter: regexp.MustCompile("^keep")}
+	defer f.Close()
+	checkPoll(t, f, true, "keep 2", "keep 3")
+
+	appendFile(t, path, "drop\n")
+	checkPoll(t, f, false, "keep 2", "keep 3")
+	appendFile(t, path, "keep 4\n")
+	checkPoll(t, f, true, "keep 3", "keep 4")
+}
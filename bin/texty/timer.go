@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// timerValues returns the placeholders for a countdown or stopwatch showing
+// d, which is truncated to whole seconds.
+func timerValues(d time.Duration) map[string]string {
+	d = max(d, 0).Truncate(time.Second)
+	days := int(d / (24 * time.Hour))
+	hours := int(d / time.Hour % 24)
+	minutes := int(d / time.Minute % 60)
+	seconds := int(d / time.Second % 60)
+
+	formatted := fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	if days > 0 {
+		formatted = fmt.Sprintf("%dd %s", days, formatted)
+	}
+
+	return map[string]string{
+		"time":          formatted,
+		"days":          strconv.Itoa(days),
+		"hours":         fmt.Sprintf("%02d", hours),
+		"minutes":       fmt.Sprintf("%02d", minutes),
+		"seconds":       fmt.Sprintf("%02d", seconds),
+		"total-hours":   strconv.Itoa(int(d / time.Hour)),
+		"total-minutes": strconv.Itoa(int(d / time.Minute)),
+		"total-seconds": strconv.Itoa(int(d / time.Second)),
+	}
+}
+
+// countdownLoop displays the time left until the window's countdown ends,
+// then runs its on-finish command.
+func (w *window) countdownLoop() {
+	c := w.config.Countdown
+	for !w.closed {
+		// rounded up, so the countdown shows 00:00:01 during its last second
+		remaining := time.Until(c.To)
+		shown := (remaining + time.Second - 1).Truncate(time.Second)
+		if remaining <= 0 {
+			break
+		}
+		w.showText(expandPlaceholders(c.Format, timerValues(shown)))
+		time.Sleep(remaining - shown + time.Second)
+	}
+	if w.closed {
+		return
+	}
+
+	if c.FinishText != nil {
+		w.showText(*c.FinishText)
+	} else {
+		w.showText(expandPlaceholders(c.Format, timerValues(0)))
+	}
+
+	// countdowns that ended before texty started don't run their command
+	if len(c.OnFinish) > 0 && time.Since(c.To) < time.Minute {
+		cmd := w.commandFor(context.Background(), c.OnFinish, c.OnFinishShell)
+		if err := cmd.Run(); err != nil {
+			log.Printf("warning: on-finish command for window %s failed: %v", w.config.Id, err)
+		}
+	}
+}
+
+// stopwatch tracks the time elapsed while running. It's safe for concurrent
+// use.
+type stopwatch struct {
+	mu sync.Mutex
+	// time accumulated before the current run
+	elapsed time.Duration
+	// start of the current run, zero while paused
+	started time.Time
+	// signaled when the stopwatch is started, paused or reset
+	changed chan struct{}
+}
+
+func newStopwatch(running bool) *stopwatch {
+	s := &stopwatch{changed: make(chan struct{}, 1)}
+	if running {
+		s.started = time.Now()
+	}
+	return s
+}
+
+// value returns the elapsed time and whether the stopwatch is running.
+func (s *stopwatch) value() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started.IsZero() {
+		return s.elapsed, false
+	}
+	return s.elapsed + time.Since(s.started), true
+}
+
+// toggle starts the stopwatch if it's paused and pauses it otherwise.
+func (s *stopwatch) toggle() {
+	s.mu.Lock()
+	if s.started.IsZero() {
+		s.started = time.Now()
+	} else {
+		s.elapsed += time.Since(s.started)
+		s.started = time.Time{}
+	}
+	s.mu.Unlock()
+	s.notify()
+}
+
+// reset stops the stopwatch and sets it back to zero.
+func (s *stopwatch) reset() {
+	s.mu.Lock()
+	s.elapsed = 0
+	s.started = time.Time{}
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *stopwatch) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// stopwatchLoop displays the window's stopwatch, ticking every second while
+// it's running.
+func (w *window) stopwatchLoop() {
+	for !w.closed {
+		elapsed, running := w.stopwatch.value()
+		values := timerValues(elapsed)
+		w.showText(expandPlaceholders(w.config.Stopwatch.Format, values))
+
+		// a paused stopwatch only wakes up when it's started, reset or closed
+		var tick <-chan time.Time
+		if running {
+			tick = time.After(time.Second - elapsed%time.Second)
+		}
+		select {
+		case <-tick:
+		case <-w.stopwatch.changed:
+		case <-w.done:
+			return
+		}
+	}
+}
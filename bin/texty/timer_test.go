@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"texty"
+	"time"
+)
+
+func TestTimerValues(t *testing.T) {
+	values := timerValues(26*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Millisecond)
+	checkValues(t, values, map[string]string{
+		"time":          "1d 02:03:04",
+		"days":          "1",
+		"hours":         "02",
+		"minutes":       "03",
+		"seconds":       "04",
+		"total-hours":   "26",
+		"total-minutes": "1563",
+		"total-seconds": "93784",
+	})
+
+	checkValues(t, timerValues(-time.Second), map[string]string{"time": "00:00:00"})
+}
+
+func TestStopwatch(t *testing.T) {
+	s := newStopwatch(false)
+	if elapsed, running := s.value(); elapsed != 0 || running {
+		t.Fatalf("new stopwatch = %v, %v, want 0, false", elapsed, running)
+	}
+
+	s.toggle()
+	time.Sleep(10 * time.Millisecond)
+	s.toggle()
+	elapsed, running := s.value()
+	if running || elapsed < 10*time.Millisecond {
+		t.Errorf("paused stopwatch = %v, %v, want at least 10ms, false", elapsed, running)
+	}
+	if again, _ := s.value(); again != elapsed {
+		t.Errorf("paused stopwatch changed from %v to %v", elapsed, again)
+	}
+
+	s.reset()
+	if elapsed, running := s.value(); elapsed != 0 || running {
+		t.Errorf("reset stopwatch = %v, %v, want 0, false", elapsed, running)
+	}
+}
+
+func TestStopwatchLoopClose(t *testing.T) {
+	w := &window{
+		config:    &texty.Window{Stopwatch: &texty.Stopwatch{Format: "{time}"}},
+		stopwatch: newStopwatch(false),
+		done:      make(chan struct{}),
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		w.stopwatchLoop()
+		close(stopped)
+	}()
+
+	// a paused stopwatch must still stop when its window is closed
+	w.markClosed()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stopwatchLoop didn't return after the window was closed")
+	}
+}
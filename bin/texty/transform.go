@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"texty"
+	"unicode/utf8"
+)
+
+var (
+	// Pango markup tags and entities
+	markupSyntax = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9]*(?:\s[^<>]*)?/?>|&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+	// ANSI escape sequences
+	ansiSyntax = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|[\]P_^X][^\x07\x1b]*(?:\x07|\x1b\\)?|[()*+#%].|.)`)
+)
+
+// transformer is a step of a window's transform pipeline, operating on the
+// lines of its text. Parts of lines matching syntax, such as markup tags, are
+// kept as is by operations that change or count characters.
+type transformer func(lines []string, syntax *regexp.Regexp) []string
+
+// newTransformers compiles the operations of a transform pipeline.
+func newTransformers(transforms []*texty.Transform) ([]transformer, error) {
+	var transformers []transformer
+	for _, t := range transforms {
+		tr, err := newTransformer(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform %s: %w", t.Op, err)
+		}
+		transformers = append(transformers, tr)
+	}
+	return transformers, nil
+}
+
+func newTransformer(t *texty.Transform) (transformer, error) {
+	switch t.Op {
+	case "trim":
+		return mapLines(strings.TrimSpace), nil
+	case "upper":
+		return mapText(strings.ToUpper), nil
+	case "lower":
+		return mapText(strings.ToLower), nil
+	case "truncate":
+		n, err := strconv.Atoi(t.Arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(lines []string, syntax *regexp.Regexp) []string {
+			for i, line := range lines {
+				lines[i] = truncate(line, n, syntax)
+			}
+			return lines
+		}, nil
+	case "replace":
+		re, err := regexp.Compile(t.Arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		return mapLines(func(line string) string { return re.ReplaceAllString(line, t.Arguments[1]) }), nil
+	case "grep":
+		re, err := regexp.Compile(t.Arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(lines []string, _ *regexp.Regexp) []string {
+			return slices.DeleteFunc(lines, func(line string) bool { return !re.MatchString(line) })
+		}, nil
+	case "head":
+		n, err := strconv.Atoi(t.Arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(lines []string, _ *regexp.Regexp) []string { return lines[:min(n, len(lines))] }, nil
+	case "tail":
+		n, err := strconv.Atoi(t.Arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(lines []string, _ *regexp.Regexp) []string { return lines[max(len(lines)-n, 0):] }, nil
+	case "sort":
+		return func(lines []string, _ *regexp.Regexp) []string {
+			slices.Sort(lines)
+			return lines
+		}, nil
+	case "uniq":
+		// like uniq(1), only adjacent duplicates are removed
+		return func(lines []string, _ *regexp.Regexp) []string { return slices.Compact(lines) }, nil
+	case "squeeze-blank":
+		return func(lines []string, _ *regexp.Regexp) []string {
+			return slices.CompactFunc(lines, func(a, b string) bool {
+				return strings.TrimSpace(a) == "" && strings.TrimSpace(b) == ""
+			})
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown operation")
+}
+
+// mapLines applies f to each whole line.
+func mapLines(f func(string) string) transformer {
+	return func(lines []string, _ *regexp.Regexp) []string {
+		for i, line := range lines {
+			lines[i] = f(line)
+		}
+		return lines
+	}
+}
+
+// mapText applies f to the text of each line, leaving syntax as is.
+func mapText(f func(string) string) transformer {
+	return func(lines []string, syntax *regexp.Regexp) []string {
+		for i, line := range lines {
+			if syntax == nil {
+				lines[i] = f(line)
+				continue
+			}
+			var b strings.Builder
+			last := 0
+			for _, m := range syntax.FindAllStringIndex(line, -1) {
+				b.WriteString(f(line[last:m[0]]))
+				b.WriteString(line[m[0]:m[1]])
+				last = m[1]
+			}
+			b.WriteString(f(line[last:]))
+			lines[i] = b.String()
+		}
+		return lines
+	}
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis if
+// it's cut. Parts of s matching syntax aren't counted and are kept, so tags
+// cut off are still closed, except entities, which count as one character.
+func truncate(s string, n int, syntax *regexp.Regexp) string {
+	var matches [][]int
+	if syntax != nil {
+		matches = syntax.FindAllStringIndex(s, -1)
+	}
+	isEntity := func(m []int) bool { return s[m[0]] == '&' }
+
+	length := utf8.RuneCountInString(s)
+	for _, m := range matches {
+		length -= utf8.RuneCountInString(s[m[0]:m[1]])
+		if isEntity(m) {
+			length++
+		}
+	}
+	if length <= n {
+		return s
+	}
+
+	var b strings.Builder
+	budget := n - 1
+	// write writes a part of s displayed as count characters, followed by
+	// the ellipsis once the budget is used up
+	write := func(part string, count int) {
+		b.WriteString(part)
+		budget -= count
+		if budget == 0 {
+			b.WriteString("…")
+		}
+	}
+	take := func(text string) {
+		for _, r := range text {
+			if budget <= 0 {
+				return
+			}
+			write(string(r), 1)
+		}
+	}
+
+	if budget == 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	for _, m := range matches {
+		take(s[last:m[0]])
+		switch {
+		case !isEntity(m):
+			b.WriteString(s[m[0]:m[1]])
+		case budget > 0:
+			write(s[m[0]:m[1]], 1)
+		}
+		last = m[1]
+	}
+	take(s[last:])
+	return b.String()
+}
+
+// transformSyntax returns the syntax that transforms must keep intact in the
+// text of the window: ANSI escape sequences if ansi is set, and markup unless
+// it's disabled.
+func (w *window) transformSyntax(ansi bool) *regexp.Regexp {
+	switch {
+	case ansi:
+		return ansiSyntax
+	case w.config.Markup != nil && *w.config.Markup == texty.MarkupOff:
+		return nil
+	}
+	return markupSyntax
+}
+
+// transformText applies the window's transform pipeline to text.
+func (w *window) transformText(text string, syntax *regexp.Regexp) string {
+	if len(w.transforms) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for _, transform := range w.transforms {
+		lines = transform(lines, syntax)
+	}
+	return strings.Join(lines, "\n")
+}
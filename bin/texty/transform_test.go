@@ -0,0 +1,96 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"texty"
+)
+
+func TestTransformText(t *testing.T) {
+	tests := []struct {
+		transforms []*texty.Transform
+		syntax     *regexp.Regexp
+		text       string
+		want       string
+	}{
+		{
+			[]*texty.Transform{{Op: "trim"}, {Op: "upper"}},
+			nil,
+			"  a  \n\tb",
+			"A\nB",
+		},
+		{
+			[]*texty.Transform{{Op: "truncate", Arguments: []string{"4"}}},
+			nil,
+			"abcd\nabcdé\nÀÉÎÕÜ",
+			"abcd\nabc…\nÀÉÎ…",
+		},
+		{
+			[]*texty.Transform{
+				{Op: "grep", Arguments: []string{`^\S+: error:`}},
+				{Op: "replace", Arguments: []string{`^(\S+): error: (.*)`, "$1 - $2"}},
+			},
+			nil,
+			"a.go: error: x\nwarning: y\nb.go: error: z",
+			"a.go - x\nb.go - z",
+		},
+		{
+			[]*texty.Transform{{Op: "sort"}, {Op: "uniq"}, {Op: "lower"}},
+			nil,
+			"b\nA\nb\nA\nc",
+			"a\nb\nc",
+		},
+		{
+			[]*texty.Transform{{Op: "squeeze-blank"}, {Op: "head", Arguments: []string{"4"}}},
+			nil,
+			"a\n\n \n\nb\n\nc",
+			"a\n\nb\n",
+		},
+		{
+			[]*texty.Transform{{Op: "tail", Arguments: []string{"2"}}},
+			nil,
+			"a\nb\nc",
+			"b\nc",
+		},
+		{
+			[]*texty.Transform{{Op: "tail", Arguments: []string{"5"}}},
+			nil,
+			"a\nb",
+			"a\nb",
+		},
+		{
+			[]*texty.Transform{{Op: "upper"}},
+			markupSyntax,
+			`<span foreground="red">a &amp; b</span>&#233;`,
+			`<span foreground="red">A &amp; B</span>&#233;`,
+		},
+		{
+			[]*texty.Transform{{Op: "truncate", Arguments: []string{"4"}}},
+			markupSyntax,
+			"<b>abcdef</b>\n<i>a</i>&lt;&gt;&amp;&quot;\n<i>a</i>&lt;&gt;&amp;",
+			"<b>abc…</b>\n<i>a</i>&lt;&gt;…\n<i>a</i>&lt;&gt;&amp;",
+		},
+		{
+			[]*texty.Transform{{Op: "truncate", Arguments: []string{"3"}}},
+			nil,
+			"<b>a</b>",
+			"<b…",
+		},
+		{
+			[]*texty.Transform{{Op: "lower"}, {Op: "truncate", Arguments: []string{"2"}}},
+			ansiSyntax,
+			"\x1b[1mAB\x1b[0mC",
+			"\x1b[1ma…\x1b[0m",
+		},
+	}
+	for _, tt := range tests {
+		transforms, err := newTransformers(tt.transforms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &window{transforms: transforms}
+		if got := w.transformText(tt.text, tt.syntax); got != tt.want {
+			t.Errorf("transformText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -2,24 +2,103 @@ package main
 
 import (
 	"log"
+	"regexp"
+	"sync"
+	"sync/atomic"
 	"texty"
+	"time"
 
+	"github.com/gotk3/gotk3/gdk"
 	"github.com/gotk3/gotk3/gtk"
 )
 
 type window struct {
 	closed     bool
+	hidden     bool
+	text       string
+	status     string
+	state      sourceState
 	config     *texty.Window
 	window     *gtk.Window
 	container  *gtk.Box
 	contentBox *gtk.Box
 	maxWidth   int
+	fileFilter *regexp.Regexp
+	transforms []transformer
+	table      *tableSplitter
+	metrics    []metricSource
+	stopwatch  *stopwatch
+
+	// closed once the window is closed, waking up sources waiting for events
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// last invalid markup warning, so it's only logged once
+	markupWarning string
+
+	// set while the window is hidden because of on-error hide
+	errorHidden bool
+
+	// time and text of the last successful update, used to detect staleness
+	lastUpdate time.Time
+	lastText   string
+
+	// stale suffix of a table window, displayed below the table instead of
+	// being split into cells
+	tableSuffix string
+
+	// guards refreshing and refreshPending, which prevent overlapping refreshes
+	refreshMu      sync.Mutex
+	refreshing     bool
+	refreshPending bool
+
+	// number of times a long-running command has been restarted
+	restarts atomic.Int64
+
+	// connector name of the monitor the window is displayed on, updated from the
+	// main loop and read by commands
+	output atomic.Pointer[string]
 }
 
 func newWindow(config *texty.Window, verbose bool) (*window, error) {
-	w := &window{config: config}
+	w := &window{config: config, done: make(chan struct{})}
 	var err error
 
+	if config.FileFilter != nil {
+		w.fileFilter, err = regexp.Compile(*config.FileFilter)
+		if err != nil {
+			log.Printf("error: invalid file filter: %v", err)
+			return nil, err
+		}
+	}
+
+	w.transforms, err = newTransformers(config.Transforms)
+	if err != nil {
+		log.Printf("error: %v", err)
+		return nil, err
+	}
+
+	if config.ContentFormat == texty.ContentFormatTable && config.Table != nil {
+		w.table, err = newTableSplitter(config.Table)
+		if err != nil {
+			log.Printf("error: invalid table delimiter: %v", err)
+			return nil, err
+		}
+	}
+
+	for _, metric := range config.Metrics {
+		source, err := newMetricSource(metric)
+		if err != nil {
+			log.Printf("error: %v", err)
+			return nil, err
+		}
+		w.metrics = append(w.metrics, source)
+	}
+
+	if config.Stopwatch != nil {
+		w.stopwatch = newStopwatch(config.Stopwatch.Running)
+	}
+
 	if verbose {
 		log.Printf("creating window %s", config.Id)
 	}
@@ -59,5 +138,91 @@ func newWindow(config *texty.Window, verbose bool) (*window, error) {
 
 	w.layout()
 
+	w.updateOutput()
+	w.window.Connect("map", w.updateOutput)
+
+	w.setLoading()
+	w.lastUpdate = time.Now()
+
 	return w, nil
 }
+
+// markClosed marks the window as closed, which stops its source.
+func (w *window) markClosed() {
+	w.closed = true
+	w.closeOnce.Do(func() { close(w.done) })
+}
+
+// updateOutput records the connector name of the monitor the window is
+// displayed on, e.g. DP-1, falling back to its model name. Until the window is
+// mapped, the primary monitor is assumed.
+func (w *window) updateOutput() {
+	display, err := gdk.DisplayGetDefault()
+	if err != nil {
+		return
+	}
+
+	var monitor *gdk.Monitor
+	if gdkWindow, err := w.window.GetWindow(); err == nil && gdkWindow != nil {
+		monitor, _ = display.GetMonitorAtWindow(gdkWindow)
+	}
+	if monitor == nil {
+		monitor, _ = display.GetPrimaryMonitor()
+	}
+	if monitor == nil {
+		return
+	}
+
+	name := monitorConnector(display, monitor)
+	if name == "" {
+		name = monitor.GetModel()
+	}
+	w.output.Store(&name)
+}
+
+// monitorConnector returns the connector name of monitor, or "" if it can't
+// be found.
+func monitorConnector(display *gdk.Display, monitor *gdk.Monitor) string {
+	screen, err := display.GetDefaultScreen()
+	if err != nil || screen == nil {
+		return ""
+	}
+	for i := 0; i < display.GetNMonitors(); i++ {
+		if m, err := display.GetMonitor(i); err == nil && m != nil && m.Native() == monitor.Native() {
+			return monitorPlugName(screen, i)
+		}
+	}
+	return ""
+}
+
+// refreshable reports whether the window's content can be refreshed on
+// demand, which isn't the case for sources that push their own updates.
+func (w *window) refreshable() bool {
+	if w.config.Fifo != nil || w.config.FileFollow || len(w.config.Clocks) > 0 ||
+		w.config.Calendar != nil || w.config.Agenda != nil ||
+		w.config.Countdown != nil || w.config.Stopwatch != nil {
+		return false
+	}
+	return w.config.CommandFormat == texty.CommandFormatText
+}
+
+func (w *window) setHidden(hidden bool) {
+	w.hidden = hidden
+	if hidden {
+		w.window.Hide()
+	} else if !w.errorHidden {
+		w.window.ShowAll()
+	}
+}
+
+// interval returns how often the window should be refreshed, or 0 if it
+// shouldn't be refreshed periodically.
+func (w *window) interval() time.Duration {
+	if w.config.Interval != nil {
+		return time.Duration(*w.config.Interval)
+	}
+	if len(w.metrics) > 0 {
+		return defaultMetricInterval
+	}
+	return 0
+}
@@ -10,10 +10,13 @@ import (
 
 	layershell "github.com/diamondburned/gotk-layer-shell"
 	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
 )
 
 type Config struct {
 	Styles   string    `json:"styles"`
+	ProcRoot string    `json:"proc_root"`
+	SysRoot  string    `json:"sys_root"`
 	Windows  []*Window `json:"window"`
 	Defaults Window    `json:"defaults"`
 }
@@ -22,14 +25,48 @@ type Window struct {
 	Id            string            `json:"id"`
 	Command       []string          `json:"command"`
 	CommandFormat CommandFormat     `json:"command_format"`
+	CommandShell  bool              `json:"command_shell"`
+	CommandAnsi   bool              `json:"command_ansi"`
+	ContentFormat ContentFormat     `json:"content_format"`
+	Markup        *MarkupMode       `json:"markup"`
+	Transforms    []*Transform      `json:"transform"`
+	Table         *Table            `json:"table"`
+	Env           map[string]string `json:"env"`
+	Cwd           *string           `json:"cwd"`
+	MaxLines      *int              `json:"max_lines"`
+	NewestOnTop   *bool             `json:"newest_on_top"`
 	Text          *string           `json:"text"`
 	File          *string           `json:"file"`
+	FileTail      *int              `json:"file_tail"`
+	FileFollow    bool              `json:"file_follow"`
+	FileFilter    *string           `json:"file_filter"`
+	Fifo          *string           `json:"fifo"`
+	FifoFormat    CommandFormat     `json:"fifo_format"`
+	Clocks        []*Clock          `json:"clock"`
+	Calendar      *Calendar         `json:"calendar"`
+	Agenda        *Agenda           `json:"calendar_file"`
+	Countdown     *Countdown        `json:"countdown"`
+	Stopwatch     *Stopwatch        `json:"stopwatch"`
+	Metrics       []*Metric         `json:"metrics"`
 	Interval      *TimeSpec         `json:"interval"`
+	Timeout       *TimeSpec         `json:"timeout"`
+	Overlap       *Overlap          `json:"overlap"`
+	Restart       *RestartPolicy    `json:"restart"`
+	RestartDelay  *TimeSpec         `json:"restart_delay"`
+	RestartMax    *TimeSpec         `json:"restart_max"`
+	Loading       *string           `json:"loading"`
+	Empty         *string           `json:"empty"`
+	OnError       *ErrorPolicy      `json:"on_error"`
+	ErrorText     *string           `json:"error_text"`
+	StaleAfter    *TimeSpec         `json:"stale_after"`
+	StaleSuffix   *string           `json:"stale_suffix"`
 	Position      *Position         `json:"position"`
 	Layer         *layershell.Layer `json:"layer"`
 	Style         *Style            `json:"style"`
 	Align         *gtk.Align        `json:"align"`
 	Spacing       *int              `json:"spacing"`
+	Wrap          *Wrap             `json:"wrap"`
+	Ellipsize     *Ellipsize        `json:"ellipsize"`
 }
 
 type CommandFormat int
@@ -37,6 +74,59 @@ type CommandFormat int
 const (
 	CommandFormatText CommandFormat = iota
 	CommandFormatJson
+	CommandFormatI3bar
+	CommandFormatLines
+	CommandFormatAppend
+)
+
+// ContentFormat is the format of the text displayed by a window.
+type ContentFormat int
+
+const (
+	ContentFormatMarkup ContentFormat = iota
+	ContentFormatMarkdown
+	ContentFormatTable
+)
+
+// MarkupMode decides whether text is parsed as Pango markup.
+type MarkupMode int
+
+const (
+	// MarkupOn parses text as markup, as is
+	MarkupOn MarkupMode = iota
+	// MarkupOff displays text as plain text
+	MarkupOff
+	// MarkupAuto parses text as markup when it's valid, and displays it as
+	// plain text otherwise
+	MarkupAuto
+)
+
+// Overlap decides what happens when a window is due for a refresh while the
+// previous one is still running.
+type Overlap int
+
+const (
+	OverlapSkip Overlap = iota
+	OverlapQueue
+)
+
+// RestartPolicy decides whether a long-running command is restarted after it
+// exits.
+type RestartPolicy int
+
+const (
+	RestartNever RestartPolicy = iota
+	RestartOnFailure
+	RestartAlways
+)
+
+// ErrorPolicy decides what a window displays when its source fails.
+type ErrorPolicy int
+
+const (
+	ErrorKeep ErrorPolicy = iota
+	ErrorShow
+	ErrorHide
 )
 
 type Position struct {
@@ -47,6 +137,92 @@ type Position struct {
 	Center bool `json:",arg"`
 }
 
+type Clock struct {
+	Format   string `json:"format"`
+	TimeZone string `json:"tz"`
+	Locale   string `json:"locale"`
+}
+
+// Calendar is a source that displays a month calendar, along with Months-1
+// surrounding months. Today and Weekend are Pango span attributes used to
+// highlight days.
+type Calendar struct {
+	Months      int          `json:"months"`
+	WeekStart   time.Weekday `json:"week_start"`
+	WeekNumbers bool         `json:"week_numbers"`
+	Locale      string       `json:"locale"`
+	Today       string       `json:"today"`
+	Weekend     string       `json:"weekend"`
+}
+
+// Agenda is a source that displays upcoming events from the iCalendar files
+// matching any of Files, which are glob patterns. Days limits how far ahead
+// events are looked up.
+type Agenda struct {
+	Files  []string `json:"files"`
+	Count  int      `json:"count"`
+	Days   int      `json:"days"`
+	Format string   `json:"format"`
+}
+
+// Countdown is a source that displays the time left until To. When it
+// reaches zero, OnFinish is run and FinishText is displayed, if set.
+type Countdown struct {
+	To            time.Time `json:"to"`
+	Format        string    `json:"format"`
+	FinishText    *string   `json:"finish_text"`
+	OnFinish      []string  `json:"on_finish"`
+	OnFinishShell bool      `json:"on_finish_shell"`
+}
+
+// Stopwatch is a source that displays the time elapsed while it's running. It
+// is started and paused by clicking the window.
+type Stopwatch struct {
+	Format  string `json:"format"`
+	Running bool   `json:"running"`
+}
+
+// Metric is a built-in source that displays system information, such as CPU
+// or memory usage, using a format string. Paths is only used by disk sources.
+type Metric struct {
+	Kind    string            `json:"kind"`
+	Format  string            `json:"format"`
+	Paths   []string          `json:"paths"`
+	Options map[string]string `json:"options"`
+}
+
+// Table configures how the text of a format=table window is split into cells.
+// Delimiter is either "whitespace", a single character, in which case fields
+// can be quoted like in CSV, or a regular expression. When Columns is set,
+// the last column holds the rest of the line.
+type Table struct {
+	Delimiter     string      `json:"delimiter"`
+	Header        bool        `json:"header"`
+	Align         []gtk.Align `json:"align"`
+	Columns       int         `json:"columns"`
+	ColumnSpacing int         `json:"column_spacing"`
+}
+
+// Wrap wraps the lines of a window at Width characters.
+type Wrap struct {
+	Width int            `json:"width"`
+	Mode  pango.WrapMode `json:"mode"`
+}
+
+// Ellipsize shortens the lines of a window longer than MaxChars characters,
+// replacing the start, middle or end with an ellipsis.
+type Ellipsize struct {
+	Mode     pango.EllipsizeMode `json:"mode"`
+	MaxChars int                 `json:"max_chars"`
+}
+
+// Transform is an operation applied to the text of a window before it's
+// displayed, e.g. grep with the pattern as its argument.
+type Transform struct {
+	Op        string   `json:"op"`
+	Arguments []string `json:"arguments"`
+}
+
 type Style struct {
 	String string            `json:"string"`
 	Map    map[string]string `json:"map"`
@@ -82,6 +258,22 @@ func (c *Config) GenerateCSS(verbose bool) (string, error) {
 	return styles.String(), nil
 }
 
+// hasExternalText reports whether the window displays text from an external
+// source (text, a file, a fifo or a command), as opposed to built-in sources
+// and i3bar commands, which generate their own markup.
+func (w *Window) hasExternalText() bool {
+	if w.Command != nil {
+		return w.CommandFormat != CommandFormatI3bar
+	}
+	return w.Text != nil || w.File != nil || w.Fifo != nil
+}
+
+// markupConfigurable reports whether the window's text may be displayed as
+// plain text.
+func (w *Window) markupConfigurable() bool {
+	return w.hasExternalText() && !w.CommandAnsi && w.ContentFormat != ContentFormatMarkdown
+}
+
 func (w *Window) GenerateCSS() string {
 	if w.Style == nil {
 		return ""
@@ -2,15 +2,53 @@ package texty
 
 func (c *Config) ApplyDefaults() {
 	for _, window := range c.Windows {
-		hasNoSources := window.Command == nil && window.Text == nil && window.File == nil
+		hasNoSources := window.Command == nil && window.Text == nil && window.File == nil && window.Fifo == nil &&
+			window.Clocks == nil && window.Calendar == nil && window.Agenda == nil &&
+			window.Countdown == nil && window.Stopwatch == nil && window.Metrics == nil
 		if c.Defaults.Command != nil && hasNoSources {
 			window.Command = c.Defaults.Command
+			window.CommandShell = c.Defaults.CommandShell
+			window.CommandAnsi = c.Defaults.CommandAnsi
+			window.ContentFormat = c.Defaults.ContentFormat
 		}
 		if c.Defaults.Text != nil && hasNoSources {
 			window.Text = c.Defaults.Text
+			window.ContentFormat = c.Defaults.ContentFormat
 		}
 		if c.Defaults.File != nil && hasNoSources {
 			window.File = c.Defaults.File
+			window.FileTail = c.Defaults.FileTail
+			window.FileFollow = c.Defaults.FileFollow
+			window.FileFilter = c.Defaults.FileFilter
+			window.ContentFormat = c.Defaults.ContentFormat
+		}
+		if c.Defaults.Fifo != nil && hasNoSources {
+			window.Fifo = c.Defaults.Fifo
+			window.FifoFormat = c.Defaults.FifoFormat
+		}
+
+		if c.Defaults.Clocks != nil && hasNoSources {
+			window.Clocks = c.Defaults.Clocks
+		}
+
+		if c.Defaults.Calendar != nil && hasNoSources {
+			window.Calendar = c.Defaults.Calendar
+		}
+
+		if c.Defaults.Agenda != nil && hasNoSources {
+			window.Agenda = c.Defaults.Agenda
+		}
+
+		if c.Defaults.Countdown != nil && hasNoSources {
+			window.Countdown = c.Defaults.Countdown
+		}
+
+		if c.Defaults.Stopwatch != nil && hasNoSources {
+			window.Stopwatch = c.Defaults.Stopwatch
+		}
+
+		if c.Defaults.Metrics != nil && hasNoSources {
+			window.Metrics = c.Defaults.Metrics
 		}
 
 		if c.Defaults.Interval != nil && window.Interval == nil && window.Text == nil {
@@ -18,6 +56,54 @@ func (c *Config) ApplyDefaults() {
 			window.Interval = c.Defaults.Interval
 		}
 
+		if c.Defaults.Timeout != nil && window.Timeout == nil {
+			window.Timeout = c.Defaults.Timeout
+		}
+
+		if c.Defaults.Markup != nil && window.Markup == nil && window.markupConfigurable() {
+			window.Markup = c.Defaults.Markup
+		}
+
+		if c.Defaults.Overlap != nil && window.Overlap == nil {
+			window.Overlap = c.Defaults.Overlap
+		}
+
+		if c.Defaults.Restart != nil && window.Restart == nil {
+			window.Restart = c.Defaults.Restart
+		}
+
+		if c.Defaults.RestartDelay != nil && window.RestartDelay == nil {
+			window.RestartDelay = c.Defaults.RestartDelay
+		}
+
+		if c.Defaults.RestartMax != nil && window.RestartMax == nil {
+			window.RestartMax = c.Defaults.RestartMax
+		}
+
+		if c.Defaults.Loading != nil && window.Loading == nil {
+			window.Loading = c.Defaults.Loading
+		}
+
+		if c.Defaults.Empty != nil && window.Empty == nil {
+			window.Empty = c.Defaults.Empty
+		}
+
+		if c.Defaults.OnError != nil && window.OnError == nil {
+			window.OnError = c.Defaults.OnError
+		}
+
+		if c.Defaults.ErrorText != nil && window.ErrorText == nil {
+			window.ErrorText = c.Defaults.ErrorText
+		}
+
+		if c.Defaults.StaleAfter != nil && window.StaleAfter == nil {
+			window.StaleAfter = c.Defaults.StaleAfter
+		}
+
+		if c.Defaults.StaleSuffix != nil && window.StaleSuffix == nil {
+			window.StaleSuffix = c.Defaults.StaleSuffix
+		}
+
 		if c.Defaults.Position != nil && window.Position == nil {
 			window.Position = c.Defaults.Position
 		}
@@ -34,6 +120,50 @@ func (c *Config) ApplyDefaults() {
 			window.Spacing = c.Defaults.Spacing
 		}
 
+		if c.Defaults.Wrap != nil && window.Wrap == nil && window.Ellipsize == nil {
+			window.Wrap = c.Defaults.Wrap
+		}
+
+		if c.Defaults.Ellipsize != nil && window.Ellipsize == nil && window.Wrap == nil {
+			window.Ellipsize = c.Defaults.Ellipsize
+		}
+
+		if c.Defaults.MaxLines != nil && window.MaxLines == nil {
+			window.MaxLines = c.Defaults.MaxLines
+		}
+
+		if c.Defaults.NewestOnTop != nil && window.NewestOnTop == nil {
+			window.NewestOnTop = c.Defaults.NewestOnTop
+		}
+
+		if window.ContentFormat == ContentFormatTable && window.Table == nil {
+			if c.Defaults.Table != nil {
+				window.Table = c.Defaults.Table
+			} else {
+				window.Table = newTable()
+			}
+		}
+
+		if c.Defaults.Transforms != nil && window.Transforms == nil && window.hasExternalText() {
+			window.Transforms = c.Defaults.Transforms
+		}
+
+		if c.Defaults.Cwd != nil && window.Cwd == nil {
+			window.Cwd = c.Defaults.Cwd
+		}
+
+		if c.Defaults.Env != nil {
+			if window.Env == nil {
+				window.Env = make(map[string]string, len(c.Defaults.Env))
+			}
+			for k, v := range c.Defaults.Env {
+				if _, ok := window.Env[k]; !ok {
+					// only add if not already set
+					window.Env[k] = v
+				}
+			}
+		}
+
 		if c.Defaults.Style != nil {
 			if window.Style == nil {
 				// simply copy the style
@@ -11,6 +11,7 @@ import (
 	"github.com/calico32/kdl-go"
 	layershell "github.com/diamondburned/gotk-layer-shell"
 	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
 )
 
 func (c *Config) UnmarshalKDL(doc *kdl.Document) error {
@@ -26,6 +27,24 @@ func (c *Config) UnmarshalKDL(doc *kdl.Document) error {
 			} else {
 				return fmt.Errorf("invalid path to CSS file for `styles` property: %v", node.Arguments[0])
 			}
+		case "proc-root":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("missing path for `proc-root` property")
+			}
+			if str, ok := node.Arguments[0].(kdl.String); ok {
+				c.ProcRoot = fmt.Sprint(str.Value())
+			} else {
+				return fmt.Errorf("invalid path for `proc-root` property: %v", node.Arguments[0])
+			}
+		case "sys-root":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("missing path for `sys-root` property")
+			}
+			if str, ok := node.Arguments[0].(kdl.String); ok {
+				c.SysRoot = fmt.Sprint(str.Value())
+			} else {
+				return fmt.Errorf("invalid path for `sys-root` property: %v", node.Arguments[0])
+			}
 		case "window":
 			var window Window
 			if err := window.UnmarshalKDL(node); err != nil {
@@ -51,12 +70,61 @@ var layers = map[string]layershell.Layer{
 	"background": layershell.LayerBackground,
 }
 
+var commandFormats = map[string]CommandFormat{
+	"text":   CommandFormatText,
+	"json":   CommandFormatJson,
+	"i3bar":  CommandFormatI3bar,
+	"lines":  CommandFormatLines,
+	"append": CommandFormatAppend,
+}
+
+var contentFormats = map[string]ContentFormat{
+	"markup":   ContentFormatMarkup,
+	"markdown": ContentFormatMarkdown,
+	"table":    ContentFormatTable,
+}
+
+var markupModes = map[string]MarkupMode{
+	"true":  MarkupOn,
+	"false": MarkupOff,
+	"auto":  MarkupAuto,
+}
+
+var overlaps = map[string]Overlap{
+	"skip":  OverlapSkip,
+	"queue": OverlapQueue,
+}
+
+var restartPolicies = map[string]RestartPolicy{
+	"never":      RestartNever,
+	"on-failure": RestartOnFailure,
+	"always":     RestartAlways,
+}
+
+var errorPolicies = map[string]ErrorPolicy{
+	"keep": ErrorKeep,
+	"show": ErrorShow,
+	"hide": ErrorHide,
+}
+
 var alignments = map[string]gtk.Align{
 	"left":   gtk.ALIGN_START,
 	"center": gtk.ALIGN_CENTER,
 	"right":  gtk.ALIGN_END,
 }
 
+var wrapModes = map[string]pango.WrapMode{
+	"word":      pango.WRAP_WORD,
+	"char":      pango.WRAP_CHAR,
+	"word-char": pango.WRAP_WORD_CHAR,
+}
+
+var ellipsizeModes = map[string]pango.EllipsizeMode{
+	"start":  pango.ELLIPSIZE_START,
+	"middle": pango.ELLIPSIZE_MIDDLE,
+	"end":    pango.ELLIPSIZE_END,
+}
+
 func (w *Window) UnmarshalKDL(node *kdl.Node) error {
 	if id, ok := node.Properties["id"]; ok {
 		if str, ok := id.(kdl.String); ok {
@@ -80,18 +148,72 @@ func (w *Window) UnmarshalKDL(node *kdl.Node) error {
 			}
 			if format, ok := node.Properties["format"]; ok {
 				if str, ok := format.(kdl.String); ok {
-					switch fmt.Sprint(str.Value()) {
-					case "text":
+					if commandFormat, ok := commandFormats[fmt.Sprint(str.Value())]; ok {
+						w.CommandFormat = commandFormat
+					} else if contentFormat, ok := contentFormats[fmt.Sprint(str.Value())]; ok {
+						// text output in another format
 						w.CommandFormat = CommandFormatText
-					case "json":
-						w.CommandFormat = CommandFormatJson
-					default:
+						w.ContentFormat = contentFormat
+					} else {
 						return fmt.Errorf("invalid command format: %s", str.Value())
 					}
 				} else {
 					return fmt.Errorf("invalid command format: %v", format)
 				}
 			}
+			if shell, ok := node.Properties["shell"]; ok {
+				switch fmt.Sprint(shell.Value()) {
+				case "true":
+					w.CommandShell = true
+				case "false":
+					w.CommandShell = false
+				default:
+					return fmt.Errorf("invalid command shell: %v", shell)
+				}
+			}
+			if ansi, ok := node.Properties["ansi"]; ok {
+				switch fmt.Sprint(ansi.Value()) {
+				case "true":
+					w.CommandAnsi = true
+				case "false":
+					w.CommandAnsi = false
+				default:
+					return fmt.Errorf("invalid command ansi: %v", ansi)
+				}
+			}
+		case "env":
+			if len(node.Arguments) != 0 {
+				return fmt.Errorf("env does not take arguments")
+			}
+			w.Env = make(map[string]string)
+			for _, child := range node.Children {
+				if len(child.Arguments) != 1 {
+					return fmt.Errorf("env variable %s requires exactly one value", child.Name)
+				}
+				w.Env[child.Name] = fmt.Sprint(child.Arguments[0].Value())
+			}
+		case "transform":
+			if len(node.Arguments) != 0 {
+				return fmt.Errorf("transform does not take arguments")
+			}
+			w.Transforms = make([]*Transform, 0, len(node.Children))
+			for _, child := range node.Children {
+				transform := &Transform{Op: child.Name}
+				for _, arg := range child.Arguments {
+					transform.Arguments = append(transform.Arguments, fmt.Sprint(arg.Value()))
+				}
+				w.Transforms = append(w.Transforms, transform)
+			}
+		case "cwd":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("cwd requires exactly one argument")
+			}
+			if str, ok := node.Arguments[0].(kdl.String); ok {
+				cwd := fmt.Sprint(str.Value())
+				w.Cwd = &cwd
+			} else {
+				return fmt.Errorf("invalid cwd: %v", node.Arguments[0])
+			}
 		case "text":
 			var text strings.Builder
 			for i, arg := range node.Arguments {
@@ -102,6 +224,13 @@ func (w *Window) UnmarshalKDL(node *kdl.Node) error {
 			}
 			txt := text.String()
 			w.Text = &txt
+			if format, ok := node.Properties["format"]; ok {
+				contentFormat, ok := contentFormats[fmt.Sprint(format.Value())]
+				if !ok {
+					return fmt.Errorf("invalid text format: %v", format)
+				}
+				w.ContentFormat = contentFormat
+			}
 		case "file":
 			if str, ok := node.Arguments[0].(kdl.String); ok {
 				f := fmt.Sprint(str.Value())
@@ -112,11 +241,199 @@ func (w *Window) UnmarshalKDL(node *kdl.Node) error {
 			if len(node.Arguments) > 1 {
 				return fmt.Errorf("too many arguments for file: %v", node.Arguments)
 			}
+			if tail, ok := node.Properties["tail"]; ok {
+				if i, err := strconv.Atoi(fmt.Sprint(tail.Value())); err == nil {
+					w.FileTail = &i
+				} else {
+					return fmt.Errorf("invalid file tail: %v", tail)
+				}
+			}
+			if follow, ok := node.Properties["follow"]; ok {
+				switch fmt.Sprint(follow.Value()) {
+				case "true":
+					w.FileFollow = true
+				case "false":
+					w.FileFollow = false
+				default:
+					return fmt.Errorf("invalid file follow: %v", follow)
+				}
+			}
+			if format, ok := node.Properties["format"]; ok {
+				contentFormat, ok := contentFormats[fmt.Sprint(format.Value())]
+				if !ok {
+					return fmt.Errorf("invalid file format: %v", format)
+				}
+				w.ContentFormat = contentFormat
+			}
+			if filter, ok := node.Properties["filter"]; ok {
+				if str, ok := filter.(kdl.String); ok {
+					f := fmt.Sprint(str.Value())
+					w.FileFilter = &f
+				} else {
+					return fmt.Errorf("invalid file filter: %v", filter)
+				}
+			}
+		case "fifo":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("fifo requires exactly one argument")
+			}
+			if str, ok := node.Arguments[0].(kdl.String); ok {
+				f := fmt.Sprint(str.Value())
+				w.Fifo = &f
+			} else {
+				return fmt.Errorf("invalid fifo: %v", node.Arguments[0])
+			}
+			if format, ok := node.Properties["format"]; ok {
+				if str, ok := format.(kdl.String); ok {
+					if fifoFormat, ok := commandFormats[fmt.Sprint(str.Value())]; ok {
+						w.FifoFormat = fifoFormat
+					} else {
+						return fmt.Errorf("invalid fifo format: %s", str.Value())
+					}
+				} else {
+					return fmt.Errorf("invalid fifo format: %v", format)
+				}
+			}
+		case "max-lines":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("max-lines requires exactly one argument")
+			}
+			if str, ok := node.Arguments[0].(kdl.Integer); ok {
+				i, err := strconv.Atoi(fmt.Sprint(str.Value()))
+				if err != nil {
+					return fmt.Errorf("invalid max-lines: %v", err)
+				}
+				w.MaxLines = &i
+			} else {
+				return fmt.Errorf("invalid max-lines: %v", node.Arguments[0])
+			}
+		case "newest":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("newest requires exactly one argument")
+			}
+			switch fmt.Sprint(node.Arguments[0].Value()) {
+			case "top":
+				newestOnTop := true
+				w.NewestOnTop = &newestOnTop
+			case "bottom":
+				newestOnTop := false
+				w.NewestOnTop = &newestOnTop
+			default:
+				return fmt.Errorf("invalid newest: %v", node.Arguments[0])
+			}
+		case "clock":
+			clock := new(Clock)
+			if err := clock.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid clock: %v", err)
+			}
+			w.Clocks = append(w.Clocks, clock)
+		case "calendar":
+			calendar := new(Calendar)
+			if err := calendar.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid calendar: %v", err)
+			}
+			w.Calendar = calendar
+		case "calendar-file":
+			agenda := new(Agenda)
+			if err := agenda.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid calendar-file: %v", err)
+			}
+			w.Agenda = agenda
+		case "countdown":
+			countdown := new(Countdown)
+			if err := countdown.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid countdown: %v", err)
+			}
+			w.Countdown = countdown
+		case "stopwatch":
+			stopwatch := new(Stopwatch)
+			if err := stopwatch.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid stopwatch: %v", err)
+			}
+			w.Stopwatch = stopwatch
+		case "cpu", "memory", "load", "uptime", "network", "disk",
+			"battery", "ac", "temperature", "backlight", "processes":
+			metric := new(Metric)
+			if err := metric.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid %s: %v", node.Name, err)
+			}
+			w.Metrics = append(w.Metrics, metric)
 		case "interval":
 			w.Interval = new(TimeSpec)
 			if err := w.Interval.UnmarshalKDL(node); err != nil {
 				return fmt.Errorf("invalid interval: %v", err)
 			}
+		case "timeout":
+			w.Timeout = new(TimeSpec)
+			if err := w.Timeout.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid timeout: %v", err)
+			}
+		case "markup":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("markup requires exactly one argument")
+			}
+			if mode, ok := markupModes[fmt.Sprint(node.Arguments[0].Value())]; ok {
+				w.Markup = &mode
+			} else {
+				return fmt.Errorf("invalid markup: %v", node.Arguments[0])
+			}
+		case "overlap":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("overlap requires exactly one argument")
+			}
+			if overlap, ok := overlaps[fmt.Sprint(node.Arguments[0].Value())]; ok {
+				w.Overlap = &overlap
+			} else {
+				return fmt.Errorf("invalid overlap: %v", node.Arguments[0])
+			}
+		case "restart":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("restart requires exactly one argument")
+			}
+			if policy, ok := restartPolicies[fmt.Sprint(node.Arguments[0].Value())]; ok {
+				w.Restart = &policy
+			} else {
+				return fmt.Errorf("invalid restart policy: %v", node.Arguments[0])
+			}
+		case "restart-delay":
+			w.RestartDelay = new(TimeSpec)
+			if err := w.RestartDelay.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid restart-delay: %v", err)
+			}
+		case "restart-max":
+			w.RestartMax = new(TimeSpec)
+			if err := w.RestartMax.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid restart-max: %v", err)
+			}
+		case "stale-after":
+			w.StaleAfter = new(TimeSpec)
+			if err := w.StaleAfter.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid stale-after: %v", err)
+			}
+		case "loading", "empty", "error-text", "stale-suffix":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("%s requires exactly one argument", node.Name)
+			}
+			text := fmt.Sprint(node.Arguments[0].Value())
+			switch node.Name {
+			case "loading":
+				w.Loading = &text
+			case "empty":
+				w.Empty = &text
+			case "error-text":
+				w.ErrorText = &text
+			case "stale-suffix":
+				w.StaleSuffix = &text
+			}
+		case "on-error":
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("on-error requires exactly one argument")
+			}
+			if policy, ok := errorPolicies[fmt.Sprint(node.Arguments[0].Value())]; ok {
+				w.OnError = &policy
+			} else {
+				return fmt.Errorf("invalid on-error: %v", node.Arguments[0])
+			}
 		case "position":
 			w.Position = new(Position)
 			if err := w.Position.UnmarshalKDL(node); err != nil {
@@ -164,6 +481,24 @@ func (w *Window) UnmarshalKDL(node *kdl.Node) error {
 			if len(node.Arguments) > 1 {
 				return fmt.Errorf("too many arguments for spacing: %v", node.Arguments)
 			}
+		case "table":
+			table := new(Table)
+			if err := table.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid table: %v", err)
+			}
+			w.Table = table
+		case "wrap":
+			wrap := new(Wrap)
+			if err := wrap.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid wrap: %v", err)
+			}
+			w.Wrap = wrap
+		case "ellipsize":
+			ellipsize := new(Ellipsize)
+			if err := ellipsize.UnmarshalKDL(node); err != nil {
+				return fmt.Errorf("invalid ellipsize: %v", err)
+			}
+			w.Ellipsize = ellipsize
 		default:
 			return fmt.Errorf("unknown property: %s", node.Name)
 		}
@@ -172,6 +507,126 @@ func (w *Window) UnmarshalKDL(node *kdl.Node) error {
 	return nil
 }
 
+var (
+	defaultTableDelimiter     = "whitespace"
+	defaultTableColumnSpacing = 16
+)
+
+func newTable() *Table {
+	return &Table{Delimiter: defaultTableDelimiter, ColumnSpacing: defaultTableColumnSpacing}
+}
+
+func (t *Table) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) != 0 {
+		return fmt.Errorf("table does not take arguments: %v", node.Arguments)
+	}
+
+	*t = *newTable()
+	for key, value := range node.Properties {
+		switch key {
+		case "delimiter":
+			t.Delimiter = fmt.Sprint(value.Value())
+		case "header":
+			switch fmt.Sprint(value.Value()) {
+			case "true":
+				t.Header = true
+			case "false":
+				t.Header = false
+			default:
+				return fmt.Errorf("invalid header: %v", value)
+			}
+		case "align":
+			for _, name := range strings.Fields(fmt.Sprint(value.Value())) {
+				align, ok := alignments[name]
+				if !ok {
+					return fmt.Errorf("invalid align: %s", name)
+				}
+				t.Align = append(t.Align, align)
+			}
+		case "columns":
+			columns, err := strconv.Atoi(fmt.Sprint(value.Value()))
+			if err != nil {
+				return fmt.Errorf("invalid columns: %v", value)
+			}
+			t.Columns = columns
+		case "column-spacing":
+			spacing, err := strconv.Atoi(fmt.Sprint(value.Value()))
+			if err != nil {
+				return fmt.Errorf("invalid column-spacing: %v", value)
+			}
+			t.ColumnSpacing = spacing
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	return nil
+}
+
+func (w *Wrap) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) != 0 {
+		return fmt.Errorf("wrap does not take arguments: %v", node.Arguments)
+	}
+
+	w.Mode = pango.WRAP_WORD
+	for key, value := range node.Properties {
+		switch key {
+		case "width":
+			width, err := strconv.Atoi(fmt.Sprint(value.Value()))
+			if err != nil {
+				return fmt.Errorf("invalid width: %v", value)
+			}
+			w.Width = width
+		case "mode":
+			mode, ok := wrapModes[fmt.Sprint(value.Value())]
+			if !ok {
+				return fmt.Errorf("invalid mode: %v", value)
+			}
+			w.Mode = mode
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	if w.Width == 0 {
+		return fmt.Errorf("width is required")
+	}
+	return nil
+}
+
+func (e *Ellipsize) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) > 1 {
+		return fmt.Errorf("too many arguments for ellipsize: %v", node.Arguments)
+	}
+
+	e.Mode = pango.ELLIPSIZE_END
+	if len(node.Arguments) == 1 {
+		mode, ok := ellipsizeModes[fmt.Sprint(node.Arguments[0].Value())]
+		if !ok {
+			return fmt.Errorf("invalid mode: %v", node.Arguments[0])
+		}
+		e.Mode = mode
+	}
+
+	for key, value := range node.Properties {
+		switch key {
+		case "max-chars":
+			maxChars, err := strconv.Atoi(fmt.Sprint(value.Value()))
+			if err != nil {
+				return fmt.Errorf("invalid max-chars: %v", value)
+			}
+			e.MaxChars = maxChars
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	if e.MaxChars == 0 {
+		return fmt.Errorf("max-chars is required")
+	}
+	return nil
+}
+
 func generateRandomId() string {
 	id := genpass.Generate(8, genpass.CharsetLower+genpass.CharsetNum)
 	return fmt.Sprintf("window-%s", id)
@@ -222,6 +677,325 @@ func (s *Style) UnmarshalKDL(node *kdl.Node) error {
 	return nil
 }
 
+var defaultClockFormat = "%H:%M"
+
+func (c *Clock) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) != 0 {
+		return fmt.Errorf("clock does not take arguments: %v", node.Arguments)
+	}
+
+	c.Format = defaultClockFormat
+	for key, value := range node.Properties {
+		str, ok := value.(kdl.String)
+		if !ok {
+			return fmt.Errorf("invalid %s: %v", key, value)
+		}
+		switch key {
+		case "format":
+			c.Format = fmt.Sprint(str.Value())
+		case "tz":
+			c.TimeZone = fmt.Sprint(str.Value())
+		case "locale":
+			c.Locale = fmt.Sprint(str.Value())
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	return nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+var (
+	defaultCalendarToday   = `weight="bold" underline="single"`
+	defaultCalendarWeekend = `alpha="60%"`
+)
+
+func (c *Calendar) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) != 0 {
+		return fmt.Errorf("calendar does not take arguments: %v", node.Arguments)
+	}
+
+	// options can be given as properties, e.g. months=3, or as child nodes,
+	// e.g. months 3
+	options := make(map[string]kdl.Value, len(node.Properties)+len(node.Children))
+	for key, value := range node.Properties {
+		options[key] = value
+	}
+	for _, child := range node.Children {
+		if len(child.Arguments) != 1 {
+			return fmt.Errorf("%s requires exactly one argument", child.Name)
+		}
+		options[child.Name] = child.Arguments[0]
+	}
+
+	c.Months = 1
+	c.WeekStart = time.Monday
+	c.Today = defaultCalendarToday
+	c.Weekend = defaultCalendarWeekend
+	for key, value := range options {
+		switch key {
+		case "months":
+			months, err := strconv.Atoi(fmt.Sprint(value.Value()))
+			if err != nil {
+				return fmt.Errorf("invalid months: %v", value)
+			}
+			c.Months = months
+		case "week-start":
+			weekday, ok := weekdays[strings.ToLower(fmt.Sprint(value.Value()))]
+			if !ok {
+				return fmt.Errorf("invalid week-start: %v", value)
+			}
+			c.WeekStart = weekday
+		case "week-numbers":
+			switch fmt.Sprint(value.Value()) {
+			case "true":
+				c.WeekNumbers = true
+			case "false":
+				c.WeekNumbers = false
+			default:
+				return fmt.Errorf("invalid week-numbers: %v", value)
+			}
+		case "locale":
+			c.Locale = fmt.Sprint(value.Value())
+		case "today":
+			c.Today = fmt.Sprint(value.Value())
+		case "weekend":
+			c.Weekend = fmt.Sprint(value.Value())
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	return nil
+}
+
+var (
+	defaultAgendaCount  = 5
+	defaultAgendaDays   = 30
+	defaultAgendaFormat = "{when}  {summary}"
+)
+
+func (a *Agenda) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) == 0 {
+		return fmt.Errorf("missing path")
+	}
+	for _, arg := range node.Arguments {
+		str, ok := arg.(kdl.String)
+		if !ok {
+			return fmt.Errorf("invalid path: %v", arg)
+		}
+		a.Files = append(a.Files, fmt.Sprint(str.Value()))
+	}
+
+	a.Count = defaultAgendaCount
+	a.Days = defaultAgendaDays
+	a.Format = defaultAgendaFormat
+	for key, value := range node.Properties {
+		switch key {
+		case "count", "days":
+			n, err := strconv.Atoi(fmt.Sprint(value.Value()))
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", key, value)
+			}
+			if key == "count" {
+				a.Count = n
+			} else {
+				a.Days = n
+			}
+		case "format":
+			a.Format = fmt.Sprint(value.Value())
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	return nil
+}
+
+var defaultTimerFormat = "{time}"
+
+// layouts accepted by the countdown `to` property; times without a time zone
+// are local
+var countdownLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseCountdownTime(value string) (time.Time, error) {
+	for _, layout := range countdownLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", value)
+}
+
+func (c *Countdown) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) != 0 {
+		return fmt.Errorf("countdown does not take arguments: %v", node.Arguments)
+	}
+
+	c.Format = defaultTimerFormat
+	for key, value := range node.Properties {
+		switch key {
+		case "to":
+			t, err := parseCountdownTime(fmt.Sprint(value.Value()))
+			if err != nil {
+				return err
+			}
+			c.To = t
+		case "format":
+			c.Format = fmt.Sprint(value.Value())
+		case "finish-text":
+			text := fmt.Sprint(value.Value())
+			c.FinishText = &text
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+	if c.To.IsZero() {
+		return fmt.Errorf("missing to")
+	}
+
+	for _, child := range node.Children {
+		switch child.Name {
+		case "on-finish":
+			if len(child.Arguments) == 0 {
+				return fmt.Errorf("on-finish requires at least one argument")
+			}
+			c.OnFinish = make([]string, len(child.Arguments))
+			for i, arg := range child.Arguments {
+				c.OnFinish[i] = fmt.Sprint(arg.Value())
+			}
+			if shell, ok := child.Properties["shell"]; ok {
+				switch fmt.Sprint(shell.Value()) {
+				case "true":
+					c.OnFinishShell = true
+				case "false":
+					c.OnFinishShell = false
+				default:
+					return fmt.Errorf("invalid on-finish shell: %v", shell)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown node: %s", child.Name)
+		}
+	}
+
+	return nil
+}
+
+func (s *Stopwatch) UnmarshalKDL(node *kdl.Node) error {
+	if len(node.Arguments) != 0 {
+		return fmt.Errorf("stopwatch does not take arguments: %v", node.Arguments)
+	}
+
+	s.Format = defaultTimerFormat
+	for key, value := range node.Properties {
+		switch key {
+		case "format":
+			s.Format = fmt.Sprint(value.Value())
+		case "running":
+			switch fmt.Sprint(value.Value()) {
+			case "true":
+				s.Running = true
+			case "false":
+				s.Running = false
+			default:
+				return fmt.Errorf("invalid running: %v", value)
+			}
+		default:
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+
+	return nil
+}
+
+var defaultMetricFormats = map[string]string{
+	"cpu":         "CPU {total}%",
+	"memory":      "Memory {used} / {total}",
+	"load":        "Load {load1} {load5} {load15}",
+	"uptime":      "Up {uptime}",
+	"network":     "{interface}: ↓{rx-rate} ↑{tx-rate}",
+	"disk":        "{path} {used} / {total} ({percent}%)",
+	"battery":     "{status} {capacity}%",
+	"ac":          "AC {state}",
+	"temperature": "{temp}°C",
+	"backlight":   "Backlight {percent}%",
+}
+
+// columns of processes sources when columns isn't set
+const defaultProcessColumns = "pid name cpu mem"
+
+// processesFormat returns the default format of a processes source, which
+// displays the given columns as a table in a monospace font.
+func processesFormat(columns string) string {
+	if columns == "" {
+		columns = defaultProcessColumns
+	}
+	fields := strings.Fields(columns)
+	for i, column := range fields {
+		fields[i] = "{" + column + "}"
+	}
+	return "<tt>" + strings.Join(fields, "  ") + "</tt>"
+}
+
+func (m *Metric) UnmarshalKDL(node *kdl.Node) error {
+	m.Kind = node.Name
+	if len(node.Arguments) != 0 && m.Kind != "disk" {
+		return fmt.Errorf("%s does not take arguments: %v", node.Name, node.Arguments)
+	}
+	for _, arg := range node.Arguments {
+		m.Paths = append(m.Paths, fmt.Sprint(arg.Value()))
+	}
+
+	m.Format = defaultMetricFormats[m.Kind]
+	m.Options = make(map[string]string)
+	for key, value := range node.Properties {
+		switch {
+		case key == "format":
+			m.Format = fmt.Sprint(value.Value())
+		case key == "path" && m.Kind == "disk":
+			// duplicate properties collapse to the last one, so several
+			// paths need arguments or path child nodes
+			m.Paths = append(m.Paths, fmt.Sprint(value.Value()))
+		default:
+			m.Options[key] = fmt.Sprint(value.Value())
+		}
+	}
+
+	for _, child := range node.Children {
+		if child.Name != "path" || m.Kind != "disk" {
+			return fmt.Errorf("unknown child node: %s", child.Name)
+		}
+		if len(child.Arguments) != 1 {
+			return fmt.Errorf("path requires exactly one argument")
+		}
+		m.Paths = append(m.Paths, fmt.Sprint(child.Arguments[0].Value()))
+	}
+
+	if m.Kind == "processes" && m.Format == "" {
+		m.Format = processesFormat(m.Options["columns"])
+	}
+
+	return nil
+}
+
 func (p *Position) UnmarshalKDL(node *kdl.Node) error {
 	if top, ok := node.Properties["top"]; ok {
 		if i, err := strconv.Atoi(fmt.Sprint(top.Value())); err == nil {
@@ -3,6 +3,13 @@ package texty
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 func (c Config) Validate() error {
@@ -24,11 +31,114 @@ func (c Config) Validate() error {
 		if window.File != nil && *window.File != "" {
 			textSourceCount++
 		}
+		if window.Fifo != nil && *window.Fifo != "" {
+			textSourceCount++
+		}
+		if len(window.Clocks) > 0 {
+			textSourceCount++
+		}
+		if window.Calendar != nil {
+			textSourceCount++
+		}
+		if window.Agenda != nil {
+			textSourceCount++
+		}
+		if window.Countdown != nil {
+			textSourceCount++
+		}
+		if window.Stopwatch != nil {
+			textSourceCount++
+		}
+		if len(window.Metrics) > 0 {
+			textSourceCount++
+		}
 		if textSourceCount == 0 {
-			return fmt.Errorf("window #%d: one of command, text, or file is required", i)
+			return fmt.Errorf("window #%d: one of command, text, file, fifo, clock, calendar, calendar-file, countdown, stopwatch, or a built-in source is required", i)
 		}
 		if textSourceCount > 1 {
-			return fmt.Errorf("window #%d: only one of command, text, or file is allowed", i)
+			return fmt.Errorf("window #%d: only one of command, text, file, fifo, clock, calendar, calendar-file, countdown, stopwatch, or built-in sources is allowed", i)
+		}
+		if window.Calendar != nil && (window.Calendar.Months < 1 || window.Calendar.Months > 12) {
+			return fmt.Errorf("window #%d: calendar: months must be between 1 and 12", i)
+		}
+		if window.Agenda != nil {
+			for _, pattern := range window.Agenda.Files {
+				if _, err := filepath.Match(pattern, ""); err != nil {
+					return fmt.Errorf("window #%d: calendar-file: invalid pattern: %s", i, pattern)
+				}
+			}
+			if window.Agenda.Count <= 0 {
+				return fmt.Errorf("window #%d: calendar-file: count must be positive", i)
+			}
+			if window.Agenda.Days <= 0 {
+				return fmt.Errorf("window #%d: calendar-file: days must be positive", i)
+			}
+		}
+		if window.Wrap != nil && window.Ellipsize != nil {
+			return fmt.Errorf("window #%d: wrap and ellipsize cannot be set at the same time", i)
+		}
+		if window.Wrap != nil && window.Wrap.Width <= 0 {
+			return fmt.Errorf("window #%d: wrap: width must be positive", i)
+		}
+		if window.Ellipsize != nil && window.Ellipsize.MaxChars <= 0 {
+			return fmt.Errorf("window #%d: ellipsize: max-chars must be positive", i)
+		}
+		if len(window.Transforms) > 0 && !window.hasExternalText() {
+			return fmt.Errorf("window #%d: transform can only be used with text, file, fifo and command sources", i)
+		}
+		for _, transform := range window.Transforms {
+			if err := transform.Validate(); err != nil {
+				return fmt.Errorf("window #%d: transform: %s: %v", i, transform.Op, err)
+			}
+		}
+		for _, metric := range window.Metrics {
+			if err := metric.Validate(); err != nil {
+				return fmt.Errorf("window #%d: %s: %v", i, metric.Kind, err)
+			}
+		}
+		for _, clock := range window.Clocks {
+			if clock.TimeZone != "" {
+				if _, err := time.LoadLocation(clock.TimeZone); err != nil {
+					return fmt.Errorf("window #%d: clock: invalid time zone: %s", i, clock.TimeZone)
+				}
+			}
+		}
+		if window.CommandAnsi {
+			if window.CommandFormat == CommandFormatJson || window.CommandFormat == CommandFormatI3bar {
+				return fmt.Errorf("window #%d: ansi cannot be used when command format is json or i3bar", i)
+			}
+			if window.ContentFormat == ContentFormatMarkdown {
+				return fmt.Errorf("window #%d: ansi cannot be used when command format is markdown", i)
+			}
+			if window.ContentFormat == ContentFormatTable {
+				return fmt.Errorf("window #%d: ansi cannot be used when command format is table", i)
+			}
+		}
+		if window.Table != nil {
+			if window.ContentFormat != ContentFormatTable {
+				return fmt.Errorf("window #%d: table requires format=table", i)
+			}
+			if err := window.Table.Validate(); err != nil {
+				return fmt.Errorf("window #%d: table: %v", i, err)
+			}
+		}
+		if window.Markup != nil && *window.Markup != MarkupOn {
+			// these generate their own markup
+			if window.CommandAnsi {
+				return fmt.Errorf("window #%d: markup cannot be disabled when ansi is used", i)
+			}
+			if window.CommandFormat == CommandFormatI3bar {
+				return fmt.Errorf("window #%d: markup cannot be disabled when command format is i3bar", i)
+			}
+			if window.ContentFormat == ContentFormatMarkdown {
+				return fmt.Errorf("window #%d: markup cannot be disabled when format is markdown", i)
+			}
+			if !window.hasExternalText() {
+				return fmt.Errorf("window #%d: markup can only be disabled for text, file, fifo and command sources", i)
+			}
+		}
+		if window.FifoFormat != CommandFormatText && window.FifoFormat != CommandFormatJson {
+			return fmt.Errorf("window #%d: fifo format must be text or json", i)
 		}
 		if window.Interval != nil {
 			// not valid with text
@@ -36,10 +146,46 @@ func (c Config) Validate() error {
 				return fmt.Errorf("window #%d: interval is not valid with text", i)
 			}
 
-			// not valid with command format=json
+			// not valid with command format=json or format=i3bar
 			if window.CommandFormat == CommandFormatJson {
 				return fmt.Errorf("window #%d: interval cannot be used when command format is json", i)
 			}
+			if window.CommandFormat == CommandFormatI3bar {
+				return fmt.Errorf("window #%d: interval cannot be used when command format is i3bar", i)
+			}
+			if window.CommandFormat == CommandFormatLines || window.CommandFormat == CommandFormatAppend {
+				return fmt.Errorf("window #%d: interval cannot be used when command format is lines or append", i)
+			}
+
+			// not valid with file follow=true, which follows the file as it grows
+			if window.File != nil && window.FileFollow {
+				return fmt.Errorf("window #%d: interval cannot be used when following a file", i)
+			}
+
+			// not valid with clock, which ticks on its own
+			if len(window.Clocks) > 0 {
+				return fmt.Errorf("window #%d: interval is not valid with clock", i)
+			}
+
+			// not valid with calendar, which rolls over at midnight on its own
+			if window.Calendar != nil {
+				return fmt.Errorf("window #%d: interval is not valid with calendar", i)
+			}
+
+			// not valid with calendar-file, which is updated when the files change
+			if window.Agenda != nil {
+				return fmt.Errorf("window #%d: interval is not valid with calendar-file", i)
+			}
+
+			// not valid with countdown or stopwatch, which tick on their own
+			if window.Countdown != nil || window.Stopwatch != nil {
+				return fmt.Errorf("window #%d: interval is not valid with countdown or stopwatch", i)
+			}
+
+			// not valid with fifo, which is updated by its writers
+			if window.Fifo != nil && *window.Fifo != "" {
+				return fmt.Errorf("window #%d: interval is not valid with fifo", i)
+			}
 
 			// cannot be negative
 			if *window.Interval < 0 {
@@ -47,12 +193,47 @@ func (c Config) Validate() error {
 			}
 		}
 
+		if window.FileTail != nil && *window.FileTail <= 0 {
+			return fmt.Errorf("window #%d: file tail must be positive", i)
+		}
+		if window.FileFilter != nil {
+			if _, err := regexp.Compile(*window.FileFilter); err != nil {
+				return fmt.Errorf("window #%d: invalid file filter: %v", i, err)
+			}
+		}
+
+		if window.Timeout != nil {
+			if *window.Timeout < 0 {
+				return fmt.Errorf("window #%d: timeout cannot be negative", i)
+			}
+		}
+
+		if window.RestartDelay != nil && *window.RestartDelay <= 0 {
+			return fmt.Errorf("window #%d: restart-delay must be positive", i)
+		}
+		if window.RestartMax != nil && *window.RestartMax <= 0 {
+			return fmt.Errorf("window #%d: restart-max must be positive", i)
+		}
+
+		if window.StaleAfter != nil && *window.StaleAfter <= 0 {
+			return fmt.Errorf("window #%d: stale-after must be positive", i)
+		}
+
 		if window.Position != nil {
-			if window.Position.Top != nil && window.Position.Bottom != nil {
-				return fmt.Errorf("window #%d: position: top and bottom cannot be set at the same time", i)
+			if err := window.Position.Validate(); err != nil {
+				return fmt.Errorf("window #%d: position: %v", i, err)
+			}
+		}
+
+		if window.Cwd != nil {
+			if info, err := os.Stat(*window.Cwd); err != nil || !info.IsDir() {
+				return fmt.Errorf("window #%d: cwd is not a directory: %s", i, *window.Cwd)
 			}
-			if window.Position.Left != nil && window.Position.Right != nil {
-				return fmt.Errorf("window #%d: position: left and right cannot be set at the same time", i)
+		}
+
+		if window.MaxLines != nil {
+			if *window.MaxLines <= 0 {
+				return fmt.Errorf("window #%d: max-lines must be positive", i)
 			}
 		}
 
@@ -67,6 +248,18 @@ func (c Config) Validate() error {
 		return fmt.Errorf("defaults: style cannot be a string (use map instead)")
 	}
 
+	if c.ProcRoot != "" {
+		if info, err := os.Stat(c.ProcRoot); err != nil || !info.IsDir() {
+			return fmt.Errorf("proc-root: not a directory: %s", c.ProcRoot)
+		}
+	}
+
+	if c.SysRoot != "" {
+		if info, err := os.Stat(c.SysRoot); err != nil || !info.IsDir() {
+			return fmt.Errorf("sys-root: not a directory: %s", c.SysRoot)
+		}
+	}
+
 	if c.Styles != "" {
 		// validate path
 		if _, err := os.Stat(c.Styles); os.IsNotExist(err) {
@@ -76,3 +269,132 @@ func (c Config) Validate() error {
 
 	return nil
 }
+
+func (p Position) Validate() error {
+	if p.Top != nil && p.Bottom != nil {
+		return fmt.Errorf("top and bottom cannot be set at the same time")
+	}
+	if p.Left != nil && p.Right != nil {
+		return fmt.Errorf("left and right cannot be set at the same time")
+	}
+	return nil
+}
+
+func (t Table) Validate() error {
+	if t.Delimiter == "" {
+		return fmt.Errorf("delimiter cannot be empty")
+	}
+	if t.Delimiter != "whitespace" && utf8.RuneCountInString(t.Delimiter) > 1 {
+		if _, err := regexp.Compile(t.Delimiter); err != nil {
+			return fmt.Errorf("invalid delimiter: %v", err)
+		}
+	}
+	if t.Delimiter == `"` || t.Delimiter == "\r" || t.Delimiter == "\n" {
+		return fmt.Errorf("invalid delimiter: %q", t.Delimiter)
+	}
+	if t.Columns < 0 {
+		return fmt.Errorf("columns cannot be negative")
+	}
+	if t.ColumnSpacing < 0 {
+		return fmt.Errorf("column-spacing cannot be negative")
+	}
+	return nil
+}
+
+// number of arguments taken by each transform operation
+var transformArguments = map[string]int{
+	"trim":          0,
+	"upper":         0,
+	"lower":         0,
+	"truncate":      1,
+	"replace":       2,
+	"grep":          1,
+	"head":          1,
+	"tail":          1,
+	"sort":          0,
+	"uniq":          0,
+	"squeeze-blank": 0,
+}
+
+func (t Transform) Validate() error {
+	n, ok := transformArguments[t.Op]
+	if !ok {
+		return fmt.Errorf("unknown operation")
+	}
+	if len(t.Arguments) != n {
+		switch n {
+		case 0:
+			return fmt.Errorf("does not take arguments")
+		case 1:
+			return fmt.Errorf("requires exactly one argument")
+		}
+		return fmt.Errorf("requires exactly %d arguments", n)
+	}
+	switch t.Op {
+	case "truncate", "head", "tail":
+		if n, err := strconv.Atoi(t.Arguments[0]); err != nil || n <= 0 {
+			return fmt.Errorf("argument must be a positive integer")
+		}
+	case "replace", "grep":
+		if _, err := regexp.Compile(t.Arguments[0]); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+// options accepted by each kind of metric
+var metricOptions = map[string][]string{
+	"cpu":         {},
+	"memory":      {},
+	"load":        {},
+	"uptime":      {},
+	"network":     {"interface"},
+	"disk":        {"mounts"},
+	"battery":     {"name"},
+	"ac":          {"name"},
+	"temperature": {"zone", "hwmon", "label"},
+	"backlight":   {"device"},
+	"processes":   {"sort", "count", "columns", "header"},
+}
+
+// columns available to processes sources
+var processColumns = []string{"pid", "name", "user", "cpu", "mem"}
+
+func (m Metric) Validate() error {
+	allowed, ok := metricOptions[m.Kind]
+	if !ok {
+		return fmt.Errorf("unknown source")
+	}
+	for key := range m.Options {
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("unknown property: %s", key)
+		}
+	}
+	if mounts, ok := m.Options["mounts"]; ok && mounts != "true" && mounts != "false" {
+		return fmt.Errorf("invalid mounts value: %s", mounts)
+	}
+	if m.Options["zone"] != "" && m.Options["hwmon"] != "" {
+		return fmt.Errorf("only one of zone and hwmon is allowed")
+	}
+	if m.Options["label"] != "" && m.Options["hwmon"] == "" {
+		return fmt.Errorf("label requires hwmon")
+	}
+	if sort, ok := m.Options["sort"]; ok && sort != "cpu" && sort != "mem" {
+		return fmt.Errorf("sort must be cpu or mem")
+	}
+	if count, ok := m.Options["count"]; ok {
+		if n, err := strconv.Atoi(count); err != nil || n <= 0 {
+			return fmt.Errorf("count must be a positive integer")
+		}
+	}
+	for _, column := range strings.Fields(m.Options["columns"]) {
+		if !slices.Contains(processColumns, column) {
+			return fmt.Errorf("unknown column: %s", column)
+		}
+	}
+	if header, ok := m.Options["header"]; ok && header != "true" && header != "false" {
+		return fmt.Errorf("invalid header value: %s", header)
+	}
+	return nil
+}